@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// GetAccount returns the account the API key belongs to
+func (c *Client) GetAccount(ctx context.Context) (*models.Account, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/account", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var account models.Account
+	if err := c.doJSON(req, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ChangePlan moves the account to another plan, region or number of targets,
+// sending key as the Idempotency-Key header so that the request is safely retried
+func (c *Client) ChangePlan(ctx context.Context, change models.PlanChangeRequest, key string) (*models.PlanChangeResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/account/plan", nil, change)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Idempotency-Key", key)
+
+	var changeResp models.PlanChangeResponse
+	if err := c.doJSON(req, &changeResp); err != nil {
+		return nil, err
+	}
+	return &changeResp, nil
+}
+
+// CancelAccount cancels the subscription of the account and returns the
+// updated account. Canceling is idempotent, so the request is safely retried.
+func (c *Client) CancelAccount(ctx context.Context, cancel models.CancelRequest) (*models.Account, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/account/cancel", nil, cancel)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Idempotency-Key", NewIdempotencyKey())
+
+	var account models.Account
+	if err := c.doJSON(req, &account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// ExportData streams the data export of the account, a zip archive, into w
+// and returns the number of bytes written
+func (c *Client) ExportData(ctx context.Context, w io.Writer) (int64, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/account/export", nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
+	}
+	return copyBody(ctx, w, resp, "data export")
+}
@@ -0,0 +1,23 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// GetCatalog returns the subscription plans and probe regions on offer. It
+// needs no API key. APIs without a catalog answer with ErrNotFound.
+func (c *Client) GetCatalog(ctx context.Context) (*models.Catalog, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/catalog", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalog models.Catalog
+	if err := c.doJSON(req, &catalog); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
@@ -0,0 +1,172 @@
+// Package client provides a Go client for the Global Blackbox API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultBaseURL is the production Global Blackbox API endpoint
+const DefaultBaseURL = "https://api.globalblackbox.io"
+
+// DefaultUserAgent is sent when no WithUserAgent option is given
+const DefaultUserAgent = "gbx-go-client"
+
+// Client is a Global Blackbox API client. Create one with New.
+type Client struct {
+	baseURL       string
+	apiKey        string
+	userAgent     string
+	httpClient    *http.Client
+	compatibility func(Compatibility)
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL overrides the API endpoint (defaults to DefaultBaseURL)
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithAPIKey sets the API key sent in the x-api-key header
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithUserAgent sets the User-Agent header, e.g. "gbx/1.2.0". The API uses it
+// to tell whether the client version is still supported.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithCompatibilityHandler registers a function called whenever the API reports
+// that the client version is deprecated or unsupported
+func WithCompatibilityHandler(handler func(Compatibility)) Option {
+	return func(c *Client) {
+		c.compatibility = handler
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// New creates a Client configured by the given options. Unless WithHTTPClient
+// is given, idempotent requests are retried using a RetryTransport with
+// DefaultMaxRetries and DefaultRetryTimeout.
+func New(opts ...Option) *Client {
+	c := &Client{
+		baseURL:   DefaultBaseURL,
+		userAgent: DefaultUserAgent,
+		httpClient: &http.Client{
+			Transport: &RetryTransport{
+				MaxRetries: DefaultMaxRetries,
+				MaxElapsed: DefaultRetryTimeout,
+			},
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// newRequest builds a request for the given API path. A non-nil body is encoded as JSON.
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal JSON: %v", err)
+		}
+		reader = bytes.NewReader(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+
+	return req, nil
+}
+
+// do sends the request and returns the response. Any status other than 200
+// is turned into an *APIError and the response body is closed.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
+	}
+
+	if c.compatibility != nil {
+		if compat, ok := parseCompatibility(resp.Header); ok {
+			c.compatibility(compat)
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, parseAPIError(resp)
+	}
+
+	return resp, nil
+}
+
+// doJSON sends the request and decodes the JSON response body into out
+func (c *Client) doJSON(req *http.Request, out interface{}) error {
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse API response: %v", err)
+	}
+	return nil
+}
+
+// copyBody streams the response body into w, closes it and returns the number
+// of bytes written. what names the content in errors, e.g. "log file".
+func copyBody(ctx context.Context, w io.Writer, resp *http.Response, what string) (int64, error) {
+	defer resp.Body.Close()
+
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		// Report cancellation rather than the resulting "closed connection" error
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		return n, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	return n, nil
+}
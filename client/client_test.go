@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		query     url.Values
+		body      interface{}
+		wantURL   string
+		wantKey   string
+		wantAgent string
+		wantBody  string
+	}{
+		{
+			name:      "defaults",
+			wantURL:   DefaultBaseURL + "/logs",
+			wantAgent: DefaultUserAgent,
+		},
+		{
+			name:      "options",
+			opts:      []Option{WithBaseURL("http://localhost:8080/"), WithAPIKey("gbx-key"), WithUserAgent("gbx/1.2.0")},
+			query:     url.Values{"region": {"london.europe"}},
+			wantURL:   "http://localhost:8080/logs?region=london.europe",
+			wantKey:   "gbx-key",
+			wantAgent: "gbx/1.2.0",
+		},
+		{
+			name:      "JSON body",
+			body:      map[string]string{"email": "ops@example.com"},
+			wantURL:   DefaultBaseURL + "/logs",
+			wantAgent: DefaultUserAgent,
+			wantBody:  `{"email":"ops@example.com"}`,
+		},
+	}
+	for _, tt := range tests {
+		req, err := New(tt.opts...).newRequest(context.Background(), http.MethodPost, "/logs", tt.query, tt.body)
+		if err != nil {
+			t.Fatalf("%s: newRequest() error = %v", tt.name, err)
+		}
+
+		if got := req.URL.String(); got != tt.wantURL {
+			t.Errorf("%s: URL = %s, want %s", tt.name, got, tt.wantURL)
+		}
+		if got := req.Header.Get("x-api-key"); got != tt.wantKey {
+			t.Errorf("%s: x-api-key = %q, want %q", tt.name, got, tt.wantKey)
+		}
+		if got := req.Header.Get("User-Agent"); got != tt.wantAgent {
+			t.Errorf("%s: User-Agent = %q, want %q", tt.name, got, tt.wantAgent)
+		}
+
+		var body []byte
+		if req.Body != nil {
+			body, _ = io.ReadAll(req.Body)
+		}
+		if string(body) != tt.wantBody {
+			t.Errorf("%s: body = %s, want %s", tt.name, body, tt.wantBody)
+		}
+		if wantJSON := tt.wantBody != ""; (req.Header.Get("Content-Type") == "application/json") != wantJSON {
+			t.Errorf("%s: Content-Type = %q", tt.name, req.Header.Get("Content-Type"))
+		}
+	}
+}
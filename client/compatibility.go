@@ -0,0 +1,30 @@
+package client
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Values of Compatibility.Status
+const (
+	CompatibilityDeprecated  = "deprecated"
+	CompatibilityUnsupported = "unsupported"
+)
+
+// Compatibility is the API's verdict on the client version named in the
+// User-Agent header. The API sends it in the X-Gbx-Client-Status header, with
+// an optional human readable X-Gbx-Client-Message, only when the client
+// version is deprecated or no longer supported.
+type Compatibility struct {
+	Status  string
+	Message string
+}
+
+// parseCompatibility extracts the compatibility headers from a response
+func parseCompatibility(h http.Header) (Compatibility, bool) {
+	status := strings.ToLower(strings.TrimSpace(h.Get("X-Gbx-Client-Status")))
+	if status == "" || status == "ok" {
+		return Compatibility{}, false
+	}
+	return Compatibility{Status: status, Message: h.Get("X-Gbx-Client-Message")}, true
+}
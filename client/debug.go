@@ -0,0 +1,161 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// debugHeaders are the headers logged by DebugTransport
+var debugHeaders = []string{
+	"Content-Type",
+	"Content-Length",
+	"User-Agent",
+	"X-Api-Key",
+	"Idempotency-Key",
+	"Retry-After",
+	"X-Request-Id",
+	"X-Amzn-Requestid",
+	"X-Gbx-Client-Status",
+}
+
+// apiKeyField matches API keys in JSON bodies, such as the sign-up response.
+// The closing quote is optional so that a key cut by truncation is redacted too.
+var apiKeyField = regexp.MustCompile(`("api[-_]key"\s*:\s*)"[^"]*("|$)`)
+
+const redacted = "[REDACTED]"
+
+// DebugTransport is an http.RoundTripper that logs every request and response
+// (method, URL, status, timing, selected headers and a truncated body) to Out.
+// API keys are always redacted from headers and bodies.
+type DebugTransport struct {
+	// Base is the transport that sends the requests (http.DefaultTransport if nil)
+	Base http.RoundTripper
+
+	// Out receives the log lines
+	Out io.Writer
+
+	// MaxBody is the number of body bytes logged (1024 if zero)
+	MaxBody int
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	maxBody := t.MaxBody
+	if maxBody <= 0 {
+		maxBody = 1024
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL.String())
+	writeHeaders(&b, req.Header)
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			prefix, _ := io.ReadAll(io.LimitReader(body, int64(maxBody)+1))
+			body.Close()
+			writeBody(&b, req.Header, prefix, maxBody)
+		}
+	}
+	io.WriteString(t.Out, b.String())
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	b.Reset()
+	if err != nil {
+		fmt.Fprintf(&b, "<-- %s %s failed after %s: %v\n", req.Method, req.URL.String(), elapsed, err)
+		io.WriteString(t.Out, b.String())
+		return nil, err
+	}
+
+	fmt.Fprintf(&b, "<-- %s (%s)\n", resp.Status, elapsed)
+	writeHeaders(&b, resp.Header)
+	io.WriteString(t.Out, b.String())
+
+	// The body is logged once the caller has read it, so streaming is not delayed
+	resp.Body = &debugBody{
+		ReadCloser: resp.Body,
+		header:     resp.Header,
+		maxBody:    maxBody,
+		out:        t.Out,
+	}
+
+	return resp, nil
+}
+
+// debugBody captures the start of a response body and logs it when the body
+// is closed
+type debugBody struct {
+	io.ReadCloser
+	header  http.Header
+	maxBody int
+	prefix  []byte
+	out     io.Writer
+	closed  bool
+}
+
+func (d *debugBody) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	if room := d.maxBody + 1 - len(d.prefix); room > 0 {
+		d.prefix = append(d.prefix, p[:min(n, room)]...)
+	}
+	return n, err
+}
+
+func (d *debugBody) Close() error {
+	if !d.closed {
+		d.closed = true
+		var b strings.Builder
+		writeBody(&b, d.header, d.prefix, d.maxBody)
+		io.WriteString(d.out, b.String())
+	}
+	return d.ReadCloser.Close()
+}
+
+// writeHeaders logs the debug headers present in h, redacting the API key
+func writeHeaders(b *strings.Builder, h http.Header) {
+	for _, name := range debugHeaders {
+		value := h.Get(name)
+		if value == "" {
+			continue
+		}
+		if strings.EqualFold(name, "X-Api-Key") {
+			value = redacted
+		}
+		fmt.Fprintf(b, "    %s: %s\n", name, value)
+	}
+}
+
+// writeBody logs up to maxBody bytes of a JSON or text body, redacting API keys
+func writeBody(b *strings.Builder, h http.Header, body []byte, maxBody int) {
+	if len(body) == 0 {
+		return
+	}
+	contentType := h.Get("Content-Type")
+	if !strings.Contains(contentType, "json") && !strings.HasPrefix(contentType, "text/") {
+		if contentType == "" {
+			contentType = "untyped"
+		}
+		fmt.Fprintf(b, "    (%s body not shown)\n", contentType)
+		return
+	}
+	truncated := len(body) > maxBody
+	if truncated {
+		body = body[:maxBody]
+	}
+
+	text := apiKeyField.ReplaceAllString(string(body), `$1"`+redacted+`"`)
+	fmt.Fprintf(b, "    %s", strings.TrimRight(text, "\n"))
+	if truncated {
+		b.WriteString(" ...(truncated)")
+	}
+	b.WriteString("\n")
+}
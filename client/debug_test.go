@@ -0,0 +1,86 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDebugTransportRedaction(t *testing.T) {
+	tests := []struct {
+		name        string
+		reqBody     string
+		respType    string
+		respBody    string
+		maxBody     int
+		wantLogged  []string
+		wantMissing []string
+	}{
+		{
+			name:        "API key header and body",
+			reqBody:     `{"email":"ops@example.com"}`,
+			respType:    "application/json",
+			respBody:    `{"api_key": "gbx-secret", "account-id": "acc-1"}`,
+			wantLogged:  []string{"X-Api-Key: " + redacted, `"api_key": "` + redacted + `"`, `"account-id": "acc-1"`, "ops@example.com"},
+			wantMissing: []string{"gbx-secret"},
+		},
+		{
+			name:        "key cut by truncation",
+			respType:    "application/json",
+			respBody:    `{"api-key":"gbx-secret-that-is-long"}`,
+			maxBody:     20,
+			wantLogged:  []string{`"api-key":"` + redacted, "...(truncated)"},
+			wantMissing: []string{"gbx-secret"},
+		},
+		{
+			name:        "binary body",
+			respType:    "application/octet-stream",
+			respBody:    "gbx-secret",
+			wantLogged:  []string{"(application/octet-stream body not shown)"},
+			wantMissing: []string{"gbx-secret"},
+		},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		transport := &DebugTransport{
+			Out:     &out,
+			MaxBody: tt.maxBody,
+			Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Status:     "200 OK",
+					Header:     http.Header{"Content-Type": {tt.respType}},
+					Body:       io.NopCloser(strings.NewReader(tt.respBody)),
+				}, nil
+			}),
+		}
+
+		req, err := http.NewRequest(http.MethodPost, "http://gbx.test/signup", strings.NewReader(tt.reqBody))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", "gbx-secret")
+
+		resp, err := transport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("%s: RoundTrip() error = %v", tt.name, err)
+		}
+		if body, _ := io.ReadAll(resp.Body); string(body) != tt.respBody {
+			t.Errorf("%s: body = %q, want it unchanged", tt.name, body)
+		}
+		resp.Body.Close()
+
+		for _, want := range tt.wantLogged {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: %q not logged:\n%s", tt.name, want, out.String())
+			}
+		}
+		for _, secret := range tt.wantMissing {
+			if strings.Contains(out.String(), secret) {
+				t.Errorf("%s: %q logged:\n%s", tt.name, secret, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error classes an APIError can be matched against with errors.Is
+var (
+	ErrUnauthorized  = errors.New("unauthorized")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrNotFound      = errors.New("not found")
+	ErrRateLimited   = errors.New("rate limited")
+	ErrServer        = errors.New("server error")
+)
+
+// APIError is returned for every API response with a non-200 status
+type APIError struct {
+	StatusCode int
+	Status     string
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API request failed with status %s", e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	var details []string
+	if e.Code != "" {
+		details = append(details, "code: "+e.Code)
+	}
+	if e.RequestID != "" {
+		details = append(details, "request ID: "+e.RequestID)
+	}
+	if len(details) > 0 {
+		msg += " (" + strings.Join(details, ", ") + ")"
+	}
+	return msg
+}
+
+// Is reports whether the error belongs to the target error class, e.g. ErrNotFound
+func (e *APIError) Is(target error) bool {
+	return target != nil && e.class() == target
+}
+
+// class maps the status code and error code to one of the error classes
+func (e *APIError) class() error {
+	code := strings.ToLower(e.Code)
+	switch {
+	case e.StatusCode == http.StatusPaymentRequired,
+		strings.Contains(code, "quota"),
+		e.StatusCode == http.StatusTooManyRequests && e.Message == "Limit Exceeded":
+		return ErrQuotaExceeded
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= 500:
+		return ErrServer
+	}
+	return nil
+}
+
+// parseAPIError builds an APIError from a non-200 response. The API answers
+// either with {"code": ..., "message": ...} or with {"error": {...}}, and the
+// gateway in front of it with a bare {"message": ...}.
+func parseAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get("X-Amzn-Requestid")
+	}
+
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	type errorBody struct {
+		Code      string `json:"code"`
+		Message   string `json:"message"`
+		RequestID string `json:"request_id"`
+	}
+	var body struct {
+		errorBody
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(bodyBytes, &body); err != nil {
+		apiErr.Message = strings.TrimSpace(string(bodyBytes))
+		return apiErr
+	}
+
+	if len(body.Error) > 0 {
+		var nested errorBody
+		if err := json.Unmarshal(body.Error, &nested); err == nil {
+			body.errorBody = nested
+		} else {
+			json.Unmarshal(body.Error, &body.Message)
+		}
+	}
+
+	apiErr.Code = body.Code
+	apiErr.Message = body.Message
+	if body.RequestID != "" {
+		apiErr.RequestID = body.RequestID
+	}
+	return apiErr
+}
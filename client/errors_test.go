@@ -0,0 +1,114 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestParseAPIError(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		body          string
+		header        http.Header
+		wantCode      string
+		wantMessage   string
+		wantRequestID string
+		wantClass     error
+	}{
+		{
+			name:        "flat body",
+			status:      http.StatusUnauthorized,
+			body:        `{"code": "invalid_api_key", "message": "API key is invalid"}`,
+			wantCode:    "invalid_api_key",
+			wantMessage: "API key is invalid",
+			wantClass:   ErrUnauthorized,
+		},
+		{
+			name:          "nested body",
+			status:        http.StatusNotFound,
+			body:          `{"error": {"code": "not_found", "message": "no such target", "request_id": "req-1"}}`,
+			header:        http.Header{"X-Request-Id": {"req-header"}},
+			wantCode:      "not_found",
+			wantMessage:   "no such target",
+			wantRequestID: "req-1",
+			wantClass:     ErrNotFound,
+		},
+		{
+			name:        "error string",
+			status:      http.StatusForbidden,
+			body:        `{"error": "forbidden"}`,
+			wantMessage: "forbidden",
+			wantClass:   ErrUnauthorized,
+		},
+		{
+			name:          "gateway quota",
+			status:        http.StatusTooManyRequests,
+			body:          `{"message": "Limit Exceeded"}`,
+			header:        http.Header{"X-Amzn-Requestid": {"amzn-1"}},
+			wantMessage:   "Limit Exceeded",
+			wantRequestID: "amzn-1",
+			wantClass:     ErrQuotaExceeded,
+		},
+		{
+			name:        "gateway throttling",
+			status:      http.StatusTooManyRequests,
+			body:        `{"message": "Too Many Requests"}`,
+			wantMessage: "Too Many Requests",
+			wantClass:   ErrRateLimited,
+		},
+		{
+			name:        "quota code",
+			status:      http.StatusBadRequest,
+			body:        `{"code": "TARGET_QUOTA", "message": "too many targets"}`,
+			wantCode:    "TARGET_QUOTA",
+			wantMessage: "too many targets",
+			wantClass:   ErrQuotaExceeded,
+		},
+		{
+			name:      "payment required",
+			status:    http.StatusPaymentRequired,
+			wantClass: ErrQuotaExceeded,
+		},
+		{
+			name:        "plain text",
+			status:      http.StatusBadGateway,
+			body:        "Bad Gateway\n",
+			wantMessage: "Bad Gateway",
+			wantClass:   ErrServer,
+		},
+		{
+			name:        "bad request",
+			status:      http.StatusBadRequest,
+			body:        `{"message": "invalid date"}`,
+			wantMessage: "invalid date",
+		},
+	}
+
+	classes := []error{ErrUnauthorized, ErrQuotaExceeded, ErrNotFound, ErrRateLimited, ErrServer}
+	for _, tt := range tests {
+		header := tt.header
+		if header == nil {
+			header = http.Header{}
+		}
+		apiErr := parseAPIError(&http.Response{
+			StatusCode: tt.status,
+			Status:     http.StatusText(tt.status),
+			Header:     header,
+			Body:       io.NopCloser(strings.NewReader(tt.body)),
+		})
+
+		if apiErr.Code != tt.wantCode || apiErr.Message != tt.wantMessage || apiErr.RequestID != tt.wantRequestID {
+			t.Errorf("%s: parseAPIError() = code %q, message %q, request ID %q, want %q, %q, %q", tt.name,
+				apiErr.Code, apiErr.Message, apiErr.RequestID, tt.wantCode, tt.wantMessage, tt.wantRequestID)
+		}
+		for _, class := range classes {
+			if got := errors.Is(apiErr, class); got != (class == tt.wantClass) {
+				t.Errorf("%s: errors.Is(err, %v) = %v", tt.name, class, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// ListLogs returns the log files available for a region, target domain and date
+func (c *Client) ListLogs(ctx context.Context, query models.LogsQuery) (*models.LogsResponse, error) {
+	params := url.Values{}
+	params.Set("region", query.Region)
+	params.Set("target_domain", query.TargetDomain)
+	params.Set("date", query.Date)
+	if query.Limit > 0 {
+		params.Set("limit", strconv.Itoa(query.Limit))
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/logs", params, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var logsResp models.LogsResponse
+	if err := c.doJSON(req, &logsResp); err != nil {
+		return nil, err
+	}
+	return &logsResp, nil
+}
+
+// DownloadLog streams a log file into w and returns the number of bytes written
+func (c *Client) DownloadLog(ctx context.Context, file models.LogFile, w io.Writer) (int64, error) {
+	params := url.Values{}
+	params.Set("region", file.Region)
+	params.Set("target_domain", file.TargetDomain)
+	params.Set("date", file.Date)
+
+	req, err := c.newRequest(ctx, http.MethodGet, "/logs/"+url.PathEscape(file.FileName), params, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return 0, err
+	}
+	return copyBody(ctx, w, resp, "log file")
+}
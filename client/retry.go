@@ -0,0 +1,206 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// Retry defaults used by New when no HTTP client is given
+const (
+	DefaultMaxRetries   = 3
+	DefaultRetryTimeout = 30 * time.Second
+)
+
+// RetryTransport is an http.RoundTripper that retries transient failures
+// (timeouts, connection errors, 429, 502, 503 and 504) with jittered
+// exponential backoff, honouring the Retry-After header. An exhausted usage
+// plan quota and TLS errors are not retried. Only idempotent requests are retried:
+// GET, HEAD and OPTIONS, or any request carrying an Idempotency-Key header.
+type RetryTransport struct {
+	// Base is the transport used for each attempt (http.DefaultTransport if nil)
+	Base http.RoundTripper
+
+	// MaxRetries is the number of retries after the first attempt
+	MaxRetries int
+
+	// MaxElapsed bounds the total time spent on a request including retries.
+	// A retry whose delay would exceed it is not attempted. Zero means no limit.
+	MaxElapsed time.Duration
+
+	// BaseDelay and MaxDelay bound the exponential backoff (500ms and 10s if zero)
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	if t.MaxRetries <= 0 || !isIdempotent(req) {
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		resp, err := base.RoundTrip(attemptReq)
+		if attempt >= t.MaxRetries || !shouldRetry(req, resp, err) {
+			return resp, err
+		}
+
+		delay := t.backoff(attempt, resp)
+		if t.MaxElapsed > 0 && time.Since(start)+delay > t.MaxElapsed {
+			return resp, err
+		}
+
+		// The next attempt needs a fresh body; give up if it cannot be replayed
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, err
+			}
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return resp, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns how long to wait before the next attempt. A Retry-After
+// header from the server takes precedence over the computed backoff.
+func (t *RetryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return delay
+		}
+	}
+
+	baseDelay, maxDelay := t.BaseDelay, t.MaxDelay
+	if baseDelay <= 0 {
+		baseDelay = 500 * time.Millisecond
+	}
+	if maxDelay <= 0 {
+		maxDelay = 10 * time.Second
+	}
+
+	ceiling := maxDelay
+	if attempt < 30 && baseDelay<<attempt < maxDelay {
+		ceiling = baseDelay << attempt
+	}
+
+	// Jitter: a uniformly random delay in [ceiling/2, ceiling)
+	half := ceiling / 2
+	return half + rand.N(ceiling-half)
+}
+
+// parseRetryAfter parses a Retry-After value given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		delay := time.Until(when)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// isIdempotent reports whether the request is safe to send more than once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// shouldRetry reports whether an attempt failed in a way worth retrying
+func shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if err != nil {
+		// Do not retry once the caller has given up
+		return req.Context().Err() == nil && isTransientError(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		// An exhausted usage plan does not recover within the retry timeout
+		return resp.Header.Get("Retry-After") != "" || !isQuotaExceeded(resp)
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// isTransientError reports whether a request error may clear on retry:
+// timeouts and failed or dropped connections, but not TLS and certificate
+// errors, nor unknown hosts
+func isTransientError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		unknownCAErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		dnsErr       *net.DNSError
+	)
+	switch {
+	case errors.As(err, &verifyErr), errors.As(err, &unknownCAErr), errors.As(err, &hostnameErr),
+		errors.As(err, &invalidErr), errors.As(err, &recordErr), errors.As(err, &alertErr):
+		return false
+	case errors.As(err, &dnsErr):
+		return !dnsErr.IsNotFound
+	}
+
+	var netErr net.Error
+	var opErr *net.OpError
+	return errors.As(err, &netErr) && netErr.Timeout() ||
+		errors.As(err, &opErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// isQuotaExceeded reports whether the response is an ErrQuotaExceeded error.
+// The start of the body is read to tell, and put back for the caller.
+func isQuotaExceeded(resp *http.Response) bool {
+	prefix, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+
+	peek := *resp
+	peek.Body = io.NopCloser(bytes.NewReader(prefix))
+	return errors.Is(parseAPIError(&peek), ErrQuotaExceeded)
+}
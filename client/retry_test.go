@@ -0,0 +1,174 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"0", 0, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v, want %v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	transport := &RetryTransport{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"first retry", 0, "", 50 * time.Millisecond, 100 * time.Millisecond},
+		{"third retry", 2, "", 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 10, "", 500 * time.Millisecond, time.Second},
+		{"overflow", 100, "", 500 * time.Millisecond, time.Second},
+		{"retry-after", 0, "7", 7 * time.Second, 7*time.Second + 1},
+		{"invalid retry-after", 0, "later", 50 * time.Millisecond, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		for range 20 {
+			if got := transport.backoff(tt.attempt, resp); got < tt.min || got >= tt.max {
+				t.Errorf("%s: backoff() = %v, want in [%v, %v)", tt.name, got, tt.min, tt.max)
+				break
+			}
+		}
+	}
+}
+
+func TestRetryIdempotency(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		key          string
+		wantAttempts int
+	}{
+		{"GET", http.MethodGet, "", 3},
+		{"POST", http.MethodPost, "", 1},
+		{"POST with idempotency key", http.MethodPost, "key-1", 3},
+		{"DELETE", http.MethodDelete, "", 1},
+	}
+	for _, tt := range tests {
+		var attempts int
+		var bodies []string
+		transport := &RetryTransport{
+			MaxRetries: 2,
+			Base: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				attempts++
+				if req.Body != nil {
+					body, _ := io.ReadAll(req.Body)
+					bodies = append(bodies, string(body))
+				}
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Header:     http.Header{"Retry-After": {"0"}},
+					Body:       http.NoBody,
+				}, nil
+			}),
+		}
+
+		req, err := http.NewRequest(tt.method, "http://gbx.test/signup", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.key != "" {
+			req.Header.Set("Idempotency-Key", tt.key)
+		}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("%s: RoundTrip() error = %v", tt.name, err)
+		}
+
+		if attempts != tt.wantAttempts {
+			t.Errorf("%s: %d attempts, want %d", tt.name, attempts, tt.wantAttempts)
+		}
+		for _, body := range bodies {
+			if body != "{}" {
+				t.Errorf("%s: replayed body = %q, want %q", tt.name, body, "{}")
+			}
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	quotaBody := `{"message": "Limit Exceeded"}`
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		err        error
+		want       bool
+	}{
+		{name: "throttled", status: http.StatusTooManyRequests, body: `{"message": "Too Many Requests"}`, want: true},
+		{name: "quota exceeded", status: http.StatusTooManyRequests, body: quotaBody, want: false},
+		{name: "quota with Retry-After", status: http.StatusTooManyRequests, retryAfter: "1", body: quotaBody, want: true},
+		{name: "unavailable", status: http.StatusServiceUnavailable, want: true},
+		{name: "internal error", status: http.StatusInternalServerError, want: false},
+		{name: "bad request", status: http.StatusBadRequest, want: false},
+		{name: "connection refused", err: &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}}, want: true},
+		{name: "connection reset", err: &url.Error{Op: "Get", Err: syscall.ECONNRESET}, want: true},
+		{name: "closed connection", err: &url.Error{Op: "Get", Err: io.EOF}, want: true},
+		{name: "timeout", err: &url.Error{Op: "Get", Err: &net.DNSError{IsTimeout: true}}, want: true},
+		{name: "unknown host", err: &url.Error{Op: "Get", Err: &net.OpError{Op: "dial", Err: &net.DNSError{IsNotFound: true}}}, want: false},
+		{name: "unknown CA", err: &url.Error{Op: "Get", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, want: false},
+		{name: "TLS alert", err: &url.Error{Op: "Get", Err: &net.OpError{Op: "remote error", Err: tls.AlertError(40)}}, want: false},
+		{name: "other error", err: errors.New("unsupported protocol scheme"), want: false},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(http.MethodGet, "http://gbx.test/logs", nil)
+		var resp *http.Response
+		if tt.err == nil {
+			resp = &http.Response{
+				StatusCode: tt.status,
+				Status:     http.StatusText(tt.status),
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+		}
+
+		if got := shouldRetry(req, resp, tt.err); got != tt.want {
+			t.Errorf("%s: shouldRetry() = %v, want %v", tt.name, got, tt.want)
+		}
+		if resp != nil {
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("%s: body = %q after shouldRetry(), want %q", tt.name, body, tt.body)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// SignUp creates a new Global Blackbox account. It does not require an API key.
+// The request carries a new idempotency key, so that it is safely retried.
+func (c *Client) SignUp(ctx context.Context, signupReq models.SignupRequest) (*models.SignupResponse, error) {
+	return c.SignUpIdempotent(ctx, signupReq, NewIdempotencyKey())
+}
+
+// SignUpIdempotent creates a new Global Blackbox account, sending key as the
+// Idempotency-Key header. Repeating a sign-up with the same key, e.g. after a
+// lost response, returns the account created by the first request instead
+// of creating another one.
+func (c *Client) SignUpIdempotent(ctx context.Context, signupReq models.SignupRequest, key string) (*models.SignupResponse, error) {
+	req, err := c.newRequest(ctx, http.MethodPost, "/sign-up", nil, signupReq)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Idempotency-Key", key)
+
+	var signupResp models.SignupResponse
+	if err := c.doJSON(req, &signupResp); err != nil {
+		return nil, err
+	}
+	return &signupResp, nil
+}
+
+// NewIdempotencyKey returns a random key for SignUpIdempotent and ChangePlan
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
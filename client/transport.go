@@ -0,0 +1,90 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// TransportConfig holds the network settings applied by NewTransport
+type TransportConfig struct {
+	// ProxyURL is an explicit proxy for all requests. When empty, the
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used.
+	ProxyURL string
+
+	// CABundles are PEM files whose certificates are trusted in addition to
+	// the system roots, e.g. the root CA of an inspecting proxy
+	CABundles []string
+
+	// ClientCert and ClientKey are PEM files for mutual TLS authentication
+	ClientCert string
+	ClientKey  string
+
+	// MinTLSVersion is the minimum TLS version, "1.2" (default) or "1.3"
+	MinTLSVersion string
+}
+
+// NewTransport creates an HTTP transport with the given proxy and TLS settings
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	minVersion, err := ParseTLSVersion(cfg.MinTLSVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{MinVersion: minVersion}
+
+	if len(cfg.CABundles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		for _, bundle := range cfg.CABundles {
+			pem, err := os.ReadFile(bundle)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA bundle: %v", err)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", bundle)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and a client key are required for mutual TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// ParseTLSVersion converts "1.2" or "1.3" to the matching crypto/tls constant.
+// An empty string selects TLS 1.2.
+func ParseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unsupported minimum TLS version %q: use 1.2 or 1.3", version)
+}
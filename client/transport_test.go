@@ -0,0 +1,81 @@
+package client
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{"", tls.VersionTLS12, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"1.0", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTLSVersion(tt.version)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseTLSVersion(%q) = %v, %v, want %v", tt.version, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewTransportErrors(t *testing.T) {
+	dir := t.TempDir()
+	notPEM := filepath.Join(dir, "not.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     TransportConfig
+		wantErr string
+	}{
+		{"proxy without host", TransportConfig{ProxyURL: "proxy.internal"}, "invalid proxy URL"},
+		{"TLS version", TransportConfig{MinTLSVersion: "1.1"}, "unsupported minimum TLS version"},
+		{"missing CA bundle", TransportConfig{CABundles: []string{filepath.Join(dir, "missing.pem")}}, "failed to read CA bundle"},
+		{"CA bundle without PEM", TransportConfig{CABundles: []string{notPEM}}, "no PEM certificates"},
+		{"client cert without key", TransportConfig{ClientCert: notPEM}, "both a client certificate and a client key"},
+		{"invalid client cert", TransportConfig{ClientCert: notPEM, ClientKey: notPEM}, "failed to load client certificate"},
+	}
+	for _, tt := range tests {
+		if _, err := NewTransport(tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: NewTransport() error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestNewTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	transport, err := NewTransport(TransportConfig{CABundles: []string{bundle}, MinTLSVersion: "1.3"})
+	if err != nil {
+		t.Fatalf("NewTransport() error = %v", err)
+	}
+	resp, err := (&http.Client{Transport: transport}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET with the CA bundle: %v", err)
+	}
+	resp.Body.Close()
+	if resp.TLS.Version != tls.VersionTLS13 {
+		t.Errorf("TLS version = %x, want TLS 1.3", resp.TLS.Version)
+	}
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// Define the account command
+var accountCmd = &cobra.Command{
+	Use:   "account",
+	Short: "Inspect and manage your Global Blackbox account",
+	Long:  `Inspect and manage the Global Blackbox account of the active profile.`,
+}
+
+// Define the status subcommand
+var accountStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the payment and trial status of the account",
+	Long: `Show the payment and trial status of the account. A new account becomes usable once its
+Stripe payment is confirmed; with --wait the command polls until then, e.g. right after sign-up.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountStatus(cmd, args)
+	},
+}
+
+// Define the show subcommand
+var accountShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show the plan, quota and billing details of the account",
+	Long: `Show the account as the API knows it: email, plan and regions, the probe targets in use out of
+those allowed, the trial and billing status and the creation date. The plan, region and number of
+targets cached in the profile are refreshed on the way.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountShow(cmd, args)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountStatusCmd)
+	accountCmd.AddCommand(accountShowCmd)
+
+	accountShowCmd.Flags().StringP("output", "o", "text", "Output format (text, json or yaml)")
+
+	accountStatusCmd.Flags().Bool("wait", false, "Wait until the payment is confirmed and the account is active")
+	accountStatusCmd.Flags().Duration("interval", 10*time.Second, "Time between status checks with --wait")
+}
+
+// runAccountStatus handles the 'account status' command
+func runAccountStatus(cmd *cobra.Command, args []string) error {
+	wait, _ := cmd.Flags().GetBool("wait")
+	interval, _ := cmd.Flags().GetDuration("interval")
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+
+	apiKey, err := getAPIKey()
+	if err != nil {
+		return err
+	}
+	apiClient, err := newAPIClient(cmd, apiKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	last, err := loadSignupState()
+	if err != nil {
+		return err
+	}
+	if last != nil && last.AccountID != account.AccountID {
+		noteSignupProfile(last)
+	}
+	state, err := loadAccountSignupState(account.AccountID)
+	if err != nil {
+		return err
+	}
+
+	displayAccountStatus(account, state)
+
+	if wait && !account.Usable() {
+		if account.Status != models.AccountCanceled {
+			fmt.Printf("\nWaiting for the payment to be confirmed, checking every %s (press Ctrl+C to stop)...\n", interval)
+		}
+
+		// Waiting is a network operation bounded by --timeout as a whole
+		ctx, end := networkTime.begin(cmd.Context())
+		defer end()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for !account.Usable() && account.Status != models.AccountCanceled {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+
+			if account, err = apiClient.GetAccount(ctx); err != nil {
+				return err
+			}
+			if account.Usable() {
+				fmt.Println()
+				displayAccountStatus(account, state)
+			}
+		}
+
+		if account.Status == models.AccountCanceled {
+			return fmt.Errorf("account %s is canceled", account.AccountID)
+		}
+	}
+
+	// The sign-up is complete once the account can be used
+	if state != nil && account.Usable() {
+		return removeAccountSignupState(account.AccountID)
+	}
+	return nil
+}
+
+// runAccountShow handles the 'account show' command
+func runAccountShow(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	if err := validateOutput(output, "text", "json", "yaml"); err != nil {
+		return err
+	}
+
+	apiKey, err := getAPIKey()
+	if err != nil {
+		return err
+	}
+	apiClient, err := newAPIClient(cmd, apiKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if err := refreshProfile(account); err != nil {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: could not update the profile: %v\n", warningStyle.Render("Warning"), err)
+	}
+
+	if output != "text" {
+		return printStructured(output, account)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Account ID:\t%s\n", account.AccountID)
+	fmt.Fprintf(w, "Email:\t%s\n", valueOrNone(account.Email))
+	fmt.Fprintf(w, "Plan:\t%s\n", account.Plan.Name)
+	fmt.Fprintf(w, "Regions:\t%s\n", accountRegions(account))
+	fmt.Fprintf(w, "Targets:\t%d of %d used\n", account.TargetsUsed, account.Plan.NumberOfTargets)
+	if pending := account.PendingPlan; pending != nil {
+		fmt.Fprintf(w, "Pending Change:\t%s plan with %d targets, waiting for the payment\n", pending.Name, pending.NumberOfTargets)
+	}
+	fmt.Fprintf(w, "Status:\t%s\n", accountStatusText(account.Status))
+	if account.Status == models.AccountTrialing && account.TrialEndsAt != nil {
+		fmt.Fprintf(w, "Trial Ends:\t%s\n", account.TrialEndsAt.Local().Format(time.DateOnly))
+	}
+	switch {
+	case account.CanceledAt != nil:
+		fmt.Fprintf(w, "Canceled:\t%s\n", account.CanceledAt.Local().Format(time.DateOnly))
+	case account.CurrentPeriodEnd != nil && account.CancelAtPeriodEnd:
+		fmt.Fprintf(w, "Ends:\t%s, canceled at the end of the billing period\n", account.CurrentPeriodEnd.Local().Format(time.DateOnly))
+	case account.CurrentPeriodEnd != nil && account.Status != models.AccountCanceled:
+		fmt.Fprintf(w, "Renews:\t%s\n", account.CurrentPeriodEnd.Local().Format(time.DateOnly))
+	}
+	if !account.CreatedAt.IsZero() {
+		fmt.Fprintf(w, "Created:\t%s\n", account.CreatedAt.Local().Format(time.DateOnly))
+	}
+	return w.Flush()
+}
+
+// accountRegions describes the regions of an account, from the catalog when
+// the API does not list them
+func accountRegions(account *models.Account) string {
+	catalog := localCatalog()
+	codes := account.Regions
+	if len(codes) == 0 {
+		codes = planRegionCodes(account.Plan)
+	}
+
+	switch {
+	case len(codes) == 0:
+		return "-"
+	case len(codes) == 1:
+		if region, ok := catalog.LookupRegion(codes[0]); ok {
+			return fmt.Sprintf("%s (%s, %s)", region.Code, region.City, region.Country)
+		}
+		return codes[0]
+	case len(codes) == len(catalog.Regions):
+		return fmt.Sprintf("all %d regions", len(codes))
+	}
+	return strings.Join(codes, ", ")
+}
+
+// planRegionCodes returns the codes of the regions a plan probes from,
+// according to the catalog
+func planRegionCodes(plan models.SignupPlan) []string {
+	catalog := localCatalog()
+	if details, ok := catalog.LookupPlan(plan.Name); ok && !details.SingleRegion {
+		codes := []string{}
+		for _, region := range catalog.PlanRegions(details) {
+			codes = append(codes, region.Code)
+		}
+		return codes
+	}
+	if plan.Region != "" {
+		return []string{plan.Region}
+	}
+	return nil
+}
+
+// refreshProfile updates the plan and number of targets cached in the active
+// profile when it holds the credentials of the account
+func refreshProfile(account *models.Account) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	name := activeProfile(file)
+	config, exists := file.Profiles[name]
+	if !exists || config.AccountID != account.AccountID {
+		return nil
+	}
+
+	plan := models.SignupPlan{Name: account.Plan.Name, Region: account.Plan.Region}
+	if config.Plan == plan && config.NumberOfTargets == account.Plan.NumberOfTargets {
+		return nil
+	}
+	config.Plan = plan
+	config.NumberOfTargets = account.Plan.NumberOfTargets
+	return saveProfile(file, name, config)
+}
+
+// displayAccountStatus prints the status of the account and, while the payment
+// is pending, the payment link of its sign-up
+func displayAccountStatus(account *models.Account, state *signupState) {
+	style := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+
+	plan := account.Plan.Name
+	if account.Plan.Region != "" {
+		plan += " (" + account.Plan.Region + ")"
+	}
+
+	fmt.Printf("%s: %s\n", style.Render("Account ID"), account.AccountID)
+	fmt.Printf("%s: %s\n", style.Render("Plan"), plan)
+	fmt.Printf("%s: %s\n", style.Render("Status"), accountStatusText(account.Status))
+
+	if account.Status == models.AccountTrialing && account.TrialEndsAt != nil {
+		days := int(time.Until(*account.TrialEndsAt).Hours()/24 + 0.5)
+		fmt.Printf("%s: %s (in %d days)\n", style.Render("Trial Ends"), account.TrialEndsAt.Local().Format(time.DateOnly), days)
+	}
+
+	if account.Status == models.AccountPendingPayment && state != nil && state.StripeURL != "" {
+		fmt.Printf("%s: %s\n", style.Render("Stripe URL"), state.StripeURL)
+	}
+}
+
+// accountStatusText describes an account status
+func accountStatusText(status string) string {
+	switch status {
+	case models.AccountPendingPayment:
+		return "waiting for the payment"
+	case models.AccountTrialing:
+		return "active, in free trial"
+	case models.AccountActive:
+		return "active"
+	case models.AccountPastDue:
+		return "payment past due"
+	case models.AccountCanceled:
+		return "canceled"
+	}
+	return status
+}
+
+// noteSignupProfile points at the profile of a sign-up in progress when
+// another account is active
+func noteSignupProfile(state *signupState) {
+	if state.AccountID == "" {
+		return
+	}
+
+	hint := "run 'gbx account status' with the profile of that account"
+	if file, err := loadConfigFile(); err == nil {
+		if name := accountProfile(file, state.AccountID); name != "" {
+			hint = fmt.Sprintf("run 'gbx account status --profile %s' to follow it", name)
+		}
+	}
+
+	noteStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+	fmt.Fprintf(os.Stderr, "%s: the sign-up of account %s is in progress, %s\n\n", noteStyle.Render("Note"), state.AccountID, hint)
+}
+
+// accountProfile returns the name of the profile holding an account, or an
+// empty string when there is none
+func accountProfile(file *models.ConfigFile, accountID string) string {
+	if accountID == "" {
+		return ""
+	}
+
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if file.Profiles[name].AccountID == accountID {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// Define the cancel subcommand
+var accountCancelCmd = &cobra.Command{
+	Use:   "cancel",
+	Short: "Cancel the subscription of the account",
+	Long: `Cancel the subscription of the account, at the end of the current billing period or immediately.
+An immediate cancellation stops the probes and the API key at once and cannot be undone.
+
+To guard against mistakes the account ID must be typed to confirm, or given with --confirm without a terminal:
+
+  gbx account cancel --at-period-end --confirm acc-1234`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountCancel(cmd, args)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountCancelCmd)
+
+	accountCancelCmd.Flags().Bool("at-period-end", false, "Cancel when the current billing period ends")
+	accountCancelCmd.Flags().Bool("immediately", false, "Cancel now, stopping the probes and the API key")
+	accountCancelCmd.MarkFlagsMutuallyExclusive("at-period-end", "immediately")
+	accountCancelCmd.Flags().String("confirm", "", "Account ID confirming the cancellation, instead of typing it")
+}
+
+// runAccountCancel handles the 'account cancel' command
+func runAccountCancel(cmd *cobra.Command, args []string) error {
+	atPeriodEnd, _ := cmd.Flags().GetBool("at-period-end")
+	immediately, _ := cmd.Flags().GetBool("immediately")
+	confirm, _ := cmd.Flags().GetString("confirm")
+
+	if !stdinIsTerminal() {
+		switch {
+		case !atPeriodEnd && !immediately:
+			return fmt.Errorf("cannot ask when to cancel without a terminal, pass --at-period-end or --immediately")
+		case confirm == "":
+			return fmt.Errorf("cannot ask for confirmation without a terminal, pass --confirm with the account ID")
+		}
+	}
+
+	apiKey, err := getAPIKey()
+	if err != nil {
+		return err
+	}
+	apiClient, err := newAPIClient(cmd, apiKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	// Only a running billing period can be left to end
+	canEndWithPeriod := account.CurrentPeriodEnd != nil && !account.CancelAtPeriodEnd
+	switch {
+	case account.Status == models.AccountCanceled:
+		return fmt.Errorf("account %s is already canceled", account.AccountID)
+	case atPeriodEnd && account.CancelAtPeriodEnd:
+		if account.CurrentPeriodEnd == nil {
+			return fmt.Errorf("account %s is already canceled at the end of the billing period", account.AccountID)
+		}
+		return fmt.Errorf("account %s is already canceled at the end of the billing period, on %s",
+			account.AccountID, account.CurrentPeriodEnd.Local().Format(time.DateOnly))
+	case atPeriodEnd && !canEndWithPeriod:
+		return fmt.Errorf("account %s has no billing period to end, use --immediately", account.AccountID)
+	case !atPeriodEnd && !immediately:
+		if !canEndWithPeriod {
+			immediately = true
+		} else if immediately, err = promptCancelImmediately(account); err != nil {
+			return err
+		}
+	}
+
+	warningStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#696969"))
+	fmt.Println()
+	if immediately {
+		fmt.Printf("%s: account %s (%s plan) will be canceled now. Its probes stop and its API key no longer works.\n",
+			warningStyle.Render("Warning"), account.AccountID, account.Plan.Name)
+	} else {
+		fmt.Printf("%s: account %s (%s plan) will be canceled on %s, at the end of the billing period.\n",
+			warningStyle.Render("Warning"), account.AccountID, account.Plan.Name, account.CurrentPeriodEnd.Local().Format(time.DateOnly))
+	}
+	fmt.Println("Run 'gbx account export-data' first to keep a copy of your data.")
+	fmt.Println()
+
+	if err := confirmCancel(account.AccountID, confirm); err != nil {
+		return err
+	}
+
+	canceled, err := apiClient.CancelAccount(cmd.Context(), models.CancelRequest{AtPeriodEnd: !immediately})
+	if err != nil {
+		return fmt.Errorf("cancellation failed: %w", err)
+	}
+
+	successStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#D3D3D3"))
+	if canceled.Status == models.AccountCanceled {
+		fmt.Printf("%s: account %s is canceled.\n", successStyle.Render("Success"), canceled.AccountID)
+		fmt.Println("Run 'gbx auth logout' to remove its credentials from your profile.")
+		return nil
+	}
+
+	ends := "the end of the billing period"
+	if canceled.CurrentPeriodEnd != nil {
+		ends = canceled.CurrentPeriodEnd.Local().Format(time.DateOnly)
+	}
+	fmt.Printf("%s: the subscription of account %s ends on %s and will not renew.\n", successStyle.Render("Success"), canceled.AccountID, ends)
+	return nil
+}
+
+// promptCancelImmediately asks whether to cancel now or at the end of the billing period
+func promptCancelImmediately(account *models.Account) (bool, error) {
+	prompt := promptui.Select{
+		Label: "When do you want to cancel?",
+		Items: []string{
+			fmt.Sprintf("At the end of the billing period, on %s", account.CurrentPeriodEnd.Local().Format(time.DateOnly)),
+			"Immediately",
+		},
+	}
+
+	index, _, err := prompt.Run()
+	if err != nil {
+		return false, fmt.Errorf("selection prompt failed: %w", err)
+	}
+	return index == 1, nil
+}
+
+// confirmCancel checks the account ID given with --confirm, or has it typed
+func confirmCancel(accountID, confirm string) error {
+	if confirm != "" {
+		if confirm != accountID {
+			return fmt.Errorf("--confirm %q does not match account %s", confirm, accountID)
+		}
+		return nil
+	}
+
+	prompt := promptui.Prompt{
+		Label: fmt.Sprintf("Type the account ID (%s) to confirm", accountID),
+		Validate: func(input string) error {
+			if input != accountID {
+				return fmt.Errorf("the account ID does not match")
+			}
+			return nil
+		},
+	}
+	_, err := prompt.Run()
+	return err
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+// Define the export-data subcommand
+var accountExportDataCmd = &cobra.Command{
+	Use:   "export-data",
+	Short: "Download an archive of all the data of the account",
+	Long: `Download a zip archive of all the data Global Blackbox keeps about the account: its details, its
+probe targets and the index of their log files, e.g. to answer a data access request under the GDPR.
+
+The archive is saved to gbx-export-<account-id>-<date>.zip unless --file is given. It is only readable
+by you, and an existing file is not overwritten unless --force is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountExportData(cmd, args)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountExportDataCmd)
+
+	accountExportDataCmd.Flags().StringP("file", "f", "", "File to save the archive to")
+	accountExportDataCmd.Flags().Bool("force", false, "Overwrite the file if it exists")
+}
+
+// runAccountExportData handles the 'account export-data' command
+func runAccountExportData(cmd *cobra.Command, args []string) error {
+	filePath, _ := cmd.Flags().GetString("file")
+	force, _ := cmd.Flags().GetBool("force")
+
+	apiKey, err := getAPIKey()
+	if err != nil {
+		return err
+	}
+	apiClient, err := newAPIClient(cmd, apiKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if err != nil {
+		return err
+	}
+
+	if filePath == "" {
+		filePath = fmt.Sprintf("gbx-export-%s-%s.zip", account.AccountID, time.Now().Format(time.DateOnly))
+	}
+	if fileExists(filePath) && !force {
+		return fmt.Errorf("%s already exists, pass --force to overwrite it", filePath)
+	}
+
+	// The checksum lets the archive be matched with the export later on
+	hash := sha256.New()
+	var size int64
+	err = downloadToFile(filePath, 0600, func(w io.Writer) error {
+		size, err = apiClient.ExportData(cmd.Context(), io.MultiWriter(w, hash))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	style := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#D3D3D3"))
+	fmt.Printf("%s: the data of account %s has been exported to %s (%d bytes).\n", style.Render("Success"), account.AccountID, filePath, size)
+	fmt.Printf("SHA-256: %s\n", hex.EncodeToString(hash.Sum(nil)))
+	return nil
+}
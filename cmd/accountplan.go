@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+// maxDiffRegions is the number of added or removed regions listed by name
+const maxDiffRegions = 8
+
+// Define the change-plan subcommand
+var accountChangePlanCmd = &cobra.Command{
+	Use:   "change-plan",
+	Short: "Change the plan, region or number of targets of the account",
+	Long: `Change the subscription plan, the region of a single-region plan or the number of probe targets.
+
+Without flags the new plan is chosen interactively, like at sign-up. With flags, values not given are
+kept, e.g. to buy more targets:
+
+  gbx account change-plan --targets 25
+
+The regions and targets gained and lost are shown before the change is confirmed. A change that costs
+more takes effect once paid through the Stripe URL it returns, a cheaper one at once.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAccountChangePlan(cmd, args)
+	},
+}
+
+func init() {
+	accountCmd.AddCommand(accountChangePlanCmd)
+
+	accountChangePlanCmd.Flags().String("plan", "", fmt.Sprintf("New subscription plan (%s)", strings.Join(models.PlanNames, ", ")))
+	accountChangePlanCmd.Flags().String("region", "", "New region code of the single-region plan, e.g. london.europe")
+	accountChangePlanCmd.Flags().Int("targets", 0, "New number of probe targets")
+	accountChangePlanCmd.Flags().BoolP("yes", "y", false, "Change the plan without asking for confirmation")
+}
+
+// runAccountChangePlan handles the 'account change-plan' command
+func runAccountChangePlan(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	yes, _ := flags.GetBool("yes")
+	given := flags.Changed("plan") || flags.Changed("region") || flags.Changed("targets")
+
+	if !yes && !stdinIsTerminal() {
+		return fmt.Errorf("cannot confirm the plan change without a terminal, pass --yes")
+	}
+	if !given && yes {
+		return fmt.Errorf("pass --plan, --region or --targets to change the plan without prompts")
+	}
+
+	apiKey, err := getAPIKey()
+	if err != nil {
+		return err
+	}
+	apiClient, err := newAPIClient(cmd, apiKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if err != nil {
+		return err
+	}
+	if account.Status == models.AccountCanceled {
+		return fmt.Errorf("account %s is canceled", account.AccountID)
+	}
+	if pending := account.PendingPlan; pending != nil {
+		noteStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#A9A9A9"))
+		fmt.Fprintf(os.Stderr, "%s: the change to the %s plan with %d targets is waiting for its payment, a new change replaces it\n\n",
+			noteStyle.Render("Note"), pending.Name, pending.NumberOfTargets)
+	}
+
+	var plan models.SignupPlan
+	if given {
+		plan, err = planChangeFromFlags(cmd, account.Plan, !yes)
+	} else {
+		plan, err = promptPlanChange(account.Plan)
+	}
+	if err != nil {
+		return err
+	}
+
+	if plan == account.Plan {
+		return fmt.Errorf("the account already has the %s plan with %d targets", plan.Name, plan.NumberOfTargets)
+	}
+
+	displayPlanChange(account, plan)
+
+	if !yes {
+		prompt := promptui.Prompt{
+			Label:     "Apply this change",
+			IsConfirm: true,
+		}
+		if _, err := prompt.Run(); errors.Is(err, promptui.ErrAbort) {
+			return fmt.Errorf("plan change cancelled")
+		} else if err != nil {
+			return err
+		}
+	}
+
+	resp, err := apiClient.ChangePlan(cmd.Context(), models.PlanChangeRequest{Plan: plan}, client.NewIdempotencyKey())
+	if err != nil {
+		return fmt.Errorf("plan change failed: %w", err)
+	}
+
+	style := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+
+	if resp.Status == models.PlanChangePendingPayment {
+		fmt.Printf("\n%s: %s\n", style.Render("Stripe URL"), resp.StripeURL)
+		fmt.Println("The change takes effect once paid through the Stripe URL. Run 'gbx account show' afterwards to update your profile.")
+		return nil
+	}
+
+	if err := refreshProfile(&resp.Account); err != nil {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: could not update the profile: %v\n", warningStyle.Render("Warning"), err)
+	}
+
+	successStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#D3D3D3"))
+	fmt.Printf("\n%s: account %s is now on the %s plan with %d targets.\n",
+		successStyle.Render("Success"), resp.Account.AccountID, resp.Account.Plan.Name, resp.Account.Plan.NumberOfTargets)
+	return nil
+}
+
+// planChangeFromFlags applies the flags to the current plan. Changing to the
+// single-region plan needs a region, which is prompted for when allowed.
+func planChangeFromFlags(cmd *cobra.Command, current models.SignupPlan, prompt bool) (models.SignupPlan, error) {
+	flags := cmd.Flags()
+	plan := current
+
+	if flags.Changed("plan") {
+		plan.Name, _ = flags.GetString("plan")
+		if err := validatePlanName(plan.Name); err != nil {
+			return plan, err
+		}
+		if plan.Name != current.Name {
+			plan.Region = ""
+		}
+	}
+	if flags.Changed("region") {
+		if !planChoosesRegion(plan.Name) {
+			return plan, fmt.Errorf("a region can only be chosen with the single-region plan")
+		}
+		plan.Region, _ = flags.GetString("region")
+		if err := validateRegion(plan.Region); err != nil {
+			return plan, err
+		}
+	}
+	if flags.Changed("targets") {
+		plan.NumberOfTargets, _ = flags.GetInt("targets")
+		if plan.NumberOfTargets <= 0 {
+			return plan, fmt.Errorf("--targets must be a positive integer")
+		}
+	}
+
+	if planChoosesRegion(plan.Name) && plan.Region == "" {
+		if !prompt {
+			return plan, fmt.Errorf("missing --region for the %s plan", plan.Name)
+		}
+		var err error
+		if plan.Region, err = promptRegion(plan.Name); err != nil {
+			return plan, err
+		}
+	}
+	return plan, nil
+}
+
+// promptPlanChange prompts for the new plan, region and number of targets,
+// starting from the current plan
+func promptPlanChange(current models.SignupPlan) (models.SignupPlan, error) {
+	plan := current
+	plan.Name = ""
+
+	for plan.Name == "" {
+		planName, err := promptPlan()
+		if err != nil {
+			return plan, err
+		}
+
+		confirmed, err := confirmPlan(planName)
+		if err != nil {
+			return plan, err
+		}
+
+		if confirmed {
+			plan.Name = planName
+		} else {
+			fmt.Println("\n" + lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#696969")).
+				Render("Let's re-select your subscription plan.\n"))
+		}
+	}
+
+	var err error
+	keep := false
+	if planChoosesRegion(plan.Name) && current.Region != "" {
+		if keep, err = keepRegion(current.Region); err != nil {
+			return plan, err
+		}
+	}
+
+	switch {
+	case !planChoosesRegion(plan.Name):
+		plan.Region = ""
+	case !keep:
+		if plan.Region, err = promptRegion(plan.Name); err != nil {
+			return plan, err
+		}
+	}
+
+	if plan.NumberOfTargets, err = promptNumberOfTargets(current.NumberOfTargets); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// keepRegion asks whether to keep probing from the current region
+func keepRegion(region string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Keep probing from %s", region),
+		IsConfirm: true,
+		Default:   "y",
+	}
+
+	_, err := prompt.Run()
+	if errors.Is(err, promptui.ErrAbort) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// displayPlanChange shows the plan, regions and targets before and after a change
+func displayPlanChange(account *models.Account, plan models.SignupPlan) {
+	style := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+	addedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#32CD32"))
+	removedStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#DC143C"))
+
+	fmt.Println("\n" + style.Render("Plan Change:\n"))
+
+	current := account.Plan
+	if current.Name != plan.Name {
+		fmt.Printf("%s: %s → %s\n", style.Render("Plan"), current.Name, plan.Name)
+	} else {
+		fmt.Printf("%s: %s (unchanged)\n", style.Render("Plan"), plan.Name)
+	}
+
+	before := account.Regions
+	if len(before) == 0 {
+		before = planRegionCodes(current)
+	}
+	after := planRegionCodes(plan)
+
+	var added, removed []string
+	for _, code := range after {
+		if !slices.Contains(before, code) {
+			added = append(added, code)
+		}
+	}
+	for _, code := range before {
+		if !slices.Contains(after, code) {
+			removed = append(removed, code)
+		}
+	}
+
+	if len(added) == 0 && len(removed) == 0 {
+		fmt.Printf("%s: %d (unchanged)\n", style.Render("Regions"), len(after))
+	} else {
+		fmt.Printf("%s: %d → %d\n", style.Render("Regions"), len(before), len(after))
+		printRegionDiff(addedStyle.Render("+"), added)
+		printRegionDiff(removedStyle.Render("-"), removed)
+	}
+
+	if current.NumberOfTargets != plan.NumberOfTargets {
+		fmt.Printf("%s: %d → %d\n", style.Render("Targets"), current.NumberOfTargets, plan.NumberOfTargets)
+	} else {
+		fmt.Printf("%s: %d (unchanged)\n", style.Render("Targets"), plan.NumberOfTargets)
+	}
+	if account.TargetsUsed > plan.NumberOfTargets {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Printf("%s: %d targets are in use, more than the new plan allows.\n",
+			warningStyle.Render("Warning"), account.TargetsUsed)
+	}
+	fmt.Println()
+}
+
+// printRegionDiff lists the regions gained or lost by a plan change, up to maxDiffRegions
+func printRegionDiff(sign string, codes []string) {
+	for i, code := range codes {
+		if i == maxDiffRegions {
+			fmt.Printf("  %s %d more\n", sign, len(codes)-i)
+			return
+		}
+		fmt.Printf("  %s %s\n", sign, code)
+	}
+}
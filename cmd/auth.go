@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+// Define the auth command
+var authCmd = &cobra.Command{
+	Use:   "auth",
+	Short: "Log in with an existing API key, check or remove credentials",
+	Long:  `Manage the credentials gbx uses to authenticate against the Global Blackbox API.`,
+}
+
+// Define the login subcommand
+var authLoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in with an existing API key",
+	Long: `Log in with an existing API key, e.g. one shared by a teammate. The key is validated
+against the API and saved to the active profile together with the account ID and plan.
+
+The key is read from --api-key, from stdin with --with-stdin, or prompted for.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAuthLogin(cmd, args)
+	},
+}
+
+// Define the status subcommand
+var authStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the active account and whether its API key works",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAuthStatus(cmd, args)
+	},
+}
+
+// Define the logout subcommand
+var authLogoutCmd = &cobra.Command{
+	Use:   "logout",
+	Short: "Remove the credentials of the active profile",
+	Long:  `Remove the API key and account of the active profile from the configuration file and its credential store. Other settings of the profile are kept.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAuthLogout(cmd, args)
+	},
+}
+
+func init() {
+	authCmd.AddCommand(authLoginCmd)
+	authCmd.AddCommand(authStatusCmd)
+	authCmd.AddCommand(authLogoutCmd)
+
+	authLoginCmd.Flags().String("api-key", "", "API key to log in with")
+	authLoginCmd.Flags().Bool("with-stdin", false, "Read the API key from stdin")
+	authLoginCmd.Flags().Bool("force", false, "Replace the credentials of another account in the active profile")
+	authLoginCmd.MarkFlagsMutuallyExclusive("api-key", "with-stdin")
+}
+
+// runAuthLogin handles the 'auth login' command
+func runAuthLogin(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+
+	apiKey, err := readLoginAPIKey(cmd)
+	if err != nil {
+		return err
+	}
+
+	apiClient, err := newAPIClient(cmd, apiKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if errors.Is(err, client.ErrUnauthorized) {
+		return fmt.Errorf("the API key was rejected by the API: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to validate the API key: %w", err)
+	}
+
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	name := activeProfile(file)
+	config, exists := file.Profiles[name]
+	if !exists {
+		config = &models.Config{}
+	}
+	if config.AccountID != "" && config.AccountID != account.AccountID && !force {
+		return fmt.Errorf("profile %q holds the credentials of account %s, use --profile to log in to another profile or --force to replace them", name, config.AccountID)
+	}
+
+	// Replace the old key wherever it is kept
+	if err := eraseAPIKey(name, config); err != nil {
+		return err
+	}
+	config.APIKey = apiKey
+	config.AccountID = account.AccountID
+	config.Plan = models.SignupPlan{Name: account.Plan.Name, Region: account.Plan.Region}
+	config.NumberOfTargets = account.Plan.NumberOfTargets
+
+	if err := saveProfile(file, name, config); err != nil {
+		return err
+	}
+
+	successStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#D3D3D3"))
+	fmt.Printf("%s: logged in to account %s (%s plan) in profile %q.\n",
+		successStyle.Render("Success"), account.AccountID, account.Plan.Name, name)
+	return nil
+}
+
+// readLoginAPIKey reads the API key from --api-key, stdin or a prompt
+func readLoginAPIKey(cmd *cobra.Command) (string, error) {
+	apiKey, _ := cmd.Flags().GetString("api-key")
+	withStdin, _ := cmd.Flags().GetBool("with-stdin")
+
+	switch {
+	case withStdin:
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the API key from stdin: %v", err)
+		}
+		apiKey = line
+	case apiKey == "":
+		prompt := promptui.Prompt{
+			Label: "Enter your API key",
+			Mask:  '*',
+			Validate: func(input string) error {
+				if strings.TrimSpace(input) == "" {
+					return fmt.Errorf("API key cannot be empty")
+				}
+				return nil
+			},
+		}
+		var err error
+		if apiKey, err = prompt.Run(); err != nil {
+			return "", err
+		}
+	}
+
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return "", fmt.Errorf("API key cannot be empty")
+	}
+	return apiKey, nil
+}
+
+// runAuthStatus handles the 'auth status' command
+func runAuthStatus(cmd *cobra.Command, args []string) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	style := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+	fmt.Printf("%s: %s\n", style.Render("Profile"), activeProfile(file))
+
+	creds, err := resolveCredentials()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s\n", style.Render("API Key"), redactSecret(creds.APIKey))
+	fmt.Printf("%s: %s\n", style.Render("Key Source"), creds.Source)
+
+	apiClient, err := newAPIClient(cmd, creds.APIKey)
+	if err != nil {
+		return err
+	}
+
+	account, err := apiClient.GetAccount(cmd.Context())
+	if err != nil {
+		fmt.Printf("%s: %s\n", style.Render("Key Status"), "not working")
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", style.Render("Key Status"), "valid")
+	fmt.Printf("%s: %s\n", style.Render("Account ID"), account.AccountID)
+	fmt.Printf("%s: %s\n", style.Render("Email"), account.Email)
+	fmt.Printf("%s: %s\n", style.Render("Plan Name"), account.Plan.Name)
+	fmt.Printf("%s: %s\n", style.Render("Account Status"), account.Status)
+
+	if creds.AccountID != "" && creds.AccountID != account.AccountID {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: the API key belongs to account %s, but account %s is configured\n",
+			warningStyle.Render("Warning"), account.AccountID, creds.AccountID)
+	}
+	return nil
+}
+
+// runAuthLogout handles the 'auth logout' command
+func runAuthLogout(cmd *cobra.Command, args []string) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	name := activeProfile(file)
+	config, exists := file.Profiles[name]
+	if !exists {
+		return fmt.Errorf("profile %q not found in config file", name)
+	}
+
+	if err := eraseAPIKey(name, config); err != nil {
+		return err
+	}
+	config.AccountID = ""
+	config.Plan = models.SignupPlan{}
+	config.NumberOfTargets = 0
+	if err := saveProfile(file, name, config); err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed the credentials of profile %q.\n", name)
+
+	// Backups made when the file was migrated or replaced by sign-up may still hold the key
+	_, configFile, _ := configPaths()
+	if backups, _ := filepath.Glob(configFile + ".*.bak"); len(backups) > 0 {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: these backups of the config file may still contain API keys: %s\n",
+			warningStyle.Render("Warning"), strings.Join(backups, ", "))
+	}
+
+	if os.Getenv("GBX_API_KEY") != "" {
+		fmt.Println("Note: GBX_API_KEY is still set in your environment.")
+	}
+	return nil
+}
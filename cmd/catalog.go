@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+// catalogMaxAge is how long the cached catalog is used before it is refreshed
+const catalogMaxAge = 24 * time.Hour
+
+// catalogTimeout bounds refreshing the catalog, which falls back to the cache
+const catalogTimeout = 5 * time.Second
+
+// catalogCache is the content of the catalog cache file
+type catalogCache struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Catalog   *models.Catalog `json:"catalog"`
+}
+
+// catalogCachePath returns the path of the catalog cache file, in the user
+// cache directory, e.g. ~/.cache/gbx/catalog.json
+func catalogCachePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to determine cache directory: %v", err)
+	}
+	return filepath.Join(cacheDir, "gbx", "catalog.json"), nil
+}
+
+// readCatalogCache returns the cached catalog, or nil when there is none
+func readCatalogCache() (*catalogCache, error) {
+	path, err := catalogCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog cache: %v", err)
+	}
+
+	var cache catalogCache
+	if err := json.Unmarshal(data, &cache); err != nil || cache.Catalog == nil || len(cache.Catalog.Regions) == 0 {
+		return nil, fmt.Errorf("invalid catalog cache %s", path)
+	}
+	return &cache, nil
+}
+
+// writeCatalogCache caches a catalog fetched from the API
+func writeCatalogCache(catalog *models.Catalog) error {
+	path, err := catalogCachePath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(catalogCache{FetchedAt: time.Now().UTC(), Catalog: catalog}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal catalog: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// currentCatalog is the catalog used by this run, see localCatalog
+var currentCatalog *models.Catalog
+
+// localCatalog returns the cached catalog, or the one built into gbx,
+// without network access. Region and plan validation use it.
+func localCatalog() *models.Catalog {
+	if currentCatalog == nil {
+		currentCatalog = models.DefaultCatalog
+		if cache, err := readCatalogCache(); err == nil && cache != nil {
+			currentCatalog = cache.Catalog
+		}
+	}
+	return currentCatalog
+}
+
+// loadCatalog returns the catalog of plans and regions. It is refreshed from
+// the API when the cache is older than catalogMaxAge or refresh is set, and
+// falls back to the cache, then to the catalog built into gbx, when the API
+// cannot be reached or has no catalog.
+func loadCatalog(cmd *cobra.Command, refresh bool) (*models.Catalog, error) {
+	cache, _ := readCatalogCache()
+	if cache != nil && !refresh && time.Since(cache.FetchedAt) < catalogMaxAge {
+		currentCatalog = cache.Catalog
+		return cache.Catalog, nil
+	}
+
+	catalog, err := fetchCatalog(cmd)
+	if err == nil {
+		if err := writeCatalogCache(catalog); err != nil {
+			warningStyle := lipgloss.NewStyle().
+				Bold(true).
+				Foreground(lipgloss.Color("#696969"))
+			fmt.Fprintf(os.Stderr, "%s: %v\n", warningStyle.Render("Warning"), err)
+		}
+		currentCatalog = catalog
+		return catalog, nil
+	}
+	if errors.Is(err, context.Canceled) {
+		return nil, err
+	}
+
+	fallback, source := models.DefaultCatalog, "the catalog built into gbx"
+	if cache != nil {
+		fallback = cache.Catalog
+		source = "the catalog cached on " + cache.FetchedAt.Local().Format(time.DateTime)
+	}
+
+	// An API without a catalog endpoint is not worth a note
+	if !errors.Is(err, client.ErrNotFound) {
+		noteStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#A9A9A9"))
+		fmt.Fprintf(os.Stderr, "%s: could not refresh the catalog (%v), showing %s\n", noteStyle.Render("Note"), err, source)
+	}
+
+	currentCatalog = fallback
+	return fallback, nil
+}
+
+// fetchCatalog gets the catalog from the API
+func fetchCatalog(cmd *cobra.Command) (*models.Catalog, error) {
+	apiClient, err := newAPIClient(cmd, "")
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(cmd.Context(), catalogTimeout)
+	defer cancel()
+
+	catalog, err := apiClient.GetCatalog(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(catalog.Plans) == 0 || len(catalog.Regions) == 0 {
+		return nil, fmt.Errorf("the API returned an empty catalog")
+	}
+	return catalog, nil
+}
@@ -0,0 +1,204 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+// newAPIClient creates an API client for the CLI, authenticated with apiKey
+// when it is non-empty. Network settings come from the global flags, falling
+// back to the configuration file and then to the client defaults.
+func newAPIClient(cmd *cobra.Command, apiKey string) (*client.Client, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	maxRetries := client.DefaultMaxRetries
+	if config.MaxRetries != nil {
+		maxRetries = *config.MaxRetries
+	}
+	if cmd.Flags().Changed("max-retries") {
+		maxRetries, _ = cmd.Flags().GetInt("max-retries")
+	}
+
+	retryTimeout := client.DefaultRetryTimeout
+	if config.RetryTimeout > 0 {
+		retryTimeout = config.RetryTimeout
+	}
+	if cmd.Flags().Changed("retry-timeout") {
+		retryTimeout, _ = cmd.Flags().GetDuration("retry-timeout")
+	}
+
+	transport, err := client.NewTransport(transportConfig(cmd, config))
+	if err != nil {
+		return nil, err
+	}
+
+	// Trace each attempt, so retries show up in the debug output too
+	var base http.RoundTripper = transport
+	if debugEnabled(cmd) {
+		base = &client.DebugTransport{Base: transport, Out: os.Stderr}
+	}
+
+	// The time limit covers the retries and the reading of the response
+	httpClient := &http.Client{
+		Transport: &timeoutTransport{
+			Base: &client.RetryTransport{
+				Base:       base,
+				MaxRetries: maxRetries,
+				MaxElapsed: retryTimeout,
+			},
+			timer: networkTime,
+		},
+	}
+
+	apiURL, err := resolveAPIURL(cmd, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.New(
+		client.WithBaseURL(apiURL),
+		client.WithAPIKey(apiKey),
+		client.WithHTTPClient(httpClient),
+		client.WithUserAgent(userAgent()),
+		client.WithCompatibilityHandler(warnCompatibility),
+	), nil
+}
+
+// timeoutTransport is an http.RoundTripper counting each request, until its
+// response body is closed, as a network operation of the timer
+type timeoutTransport struct {
+	Base  http.RoundTripper
+	timer *networkTimer
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, end := t.timer.begin(req.Context())
+	resp, err := t.Base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		end()
+		return nil, err
+	}
+	resp.Body = &timeoutBody{ReadCloser: resp.Body, ctx: ctx, end: end}
+	return resp, nil
+}
+
+// timeoutBody ends the network operation of a response when it is closed
+type timeoutBody struct {
+	io.ReadCloser
+	ctx context.Context
+	end func()
+}
+
+// Read reports the expiry of the time limit rather than the resulting
+// "closed connection" error
+func (b *timeoutBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		err = b.ctx.Err()
+	}
+	return n, err
+}
+
+func (b *timeoutBody) Close() error {
+	defer b.end()
+	return b.ReadCloser.Close()
+}
+
+// compatibilityWarning makes sure the version warning is printed only once per run
+var compatibilityWarning sync.Once
+
+// warnCompatibility tells the user when the API reports this gbx version as
+// deprecated or unsupported
+func warnCompatibility(compat client.Compatibility) {
+	compatibilityWarning.Do(func() {
+		msg := fmt.Sprintf("gbx %s is %s by the Global Blackbox API, please upgrade", buildInfo().Version, compat.Status)
+		if compat.Message != "" {
+			msg += ": " + compat.Message
+		}
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: %s\n", warningStyle.Render("Warning"), msg)
+	})
+}
+
+// resolveAPIURL returns the API endpoint to use. The --api-url flag takes
+// precedence over the GBX_API_URL environment variable, which takes
+// precedence over api_url in the configuration file.
+func resolveAPIURL(cmd *cobra.Command, config *models.Config) (string, error) {
+	apiURL := client.DefaultBaseURL
+	source := "default"
+	if config.APIURL != "" {
+		apiURL, source = config.APIURL, "api_url in the config file"
+	}
+	if env := os.Getenv("GBX_API_URL"); env != "" {
+		apiURL, source = env, "GBX_API_URL"
+	}
+	if cmd.Flags().Changed("api-url") {
+		apiURL, _ = cmd.Flags().GetString("api-url")
+		source = "--api-url"
+	}
+
+	u, err := url.Parse(apiURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid API URL %q from %s: expected an http:// or https:// URL", apiURL, source)
+	}
+
+	return apiURL, nil
+}
+
+// transportConfig merges the proxy and TLS flags over the configuration file
+func transportConfig(cmd *cobra.Command, config *models.Config) client.TransportConfig {
+	cfg := client.TransportConfig{
+		ProxyURL:      config.ProxyURL,
+		CABundles:     config.CABundles,
+		ClientCert:    config.ClientCert,
+		ClientKey:     config.ClientKey,
+		MinTLSVersion: config.TLSMinVersion,
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("proxy") {
+		cfg.ProxyURL, _ = flags.GetString("proxy")
+	}
+	if flags.Changed("ca-bundle") {
+		cfg.CABundles, _ = flags.GetStringSlice("ca-bundle")
+	}
+	if flags.Changed("client-cert") {
+		cfg.ClientCert, _ = flags.GetString("client-cert")
+	}
+	if flags.Changed("client-key") {
+		cfg.ClientKey, _ = flags.GetString("client-key")
+	}
+	if flags.Changed("tls-min-version") {
+		cfg.MinTLSVersion, _ = flags.GetString("tls-min-version")
+	}
+
+	return cfg
+}
+
+// debugEnabled reports whether HTTP tracing was requested with --debug or GBX_DEBUG
+func debugEnabled(cmd *cobra.Command) bool {
+	if cmd.Flags().Changed("debug") {
+		debug, _ := cmd.Flags().GetBool("debug")
+		return debug
+	}
+	debug, _ := strconv.ParseBool(os.Getenv("GBX_DEBUG"))
+	return debug
+}
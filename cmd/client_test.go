@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+func TestResolveAPIURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		flag    string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: client.DefaultBaseURL},
+		{name: "config", config: "https://config.test", want: "https://config.test"},
+		{name: "env", config: "https://config.test", env: "https://env.test", want: "https://env.test"},
+		{name: "flag", config: "https://config.test", env: "https://env.test", flag: "http://localhost:8080", want: "http://localhost:8080"},
+		{name: "no scheme", flag: "api.globalblackbox.io", wantErr: true},
+		{name: "unsupported scheme", env: "ftp://env.test", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Setenv("GBX_API_URL", tt.env)
+
+		cmd := &cobra.Command{}
+		cmd.Flags().String("api-url", client.DefaultBaseURL, "")
+		if tt.flag != "" {
+			cmd.Flags().Set("api-url", tt.flag)
+		}
+
+		got, err := resolveAPIURL(cmd, &models.Config{APIURL: tt.config})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: resolveAPIURL() = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -4,36 +4,506 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
 
 	"globalblackbox.io/gbx/models"
 
 	"gopkg.in/yaml.v2"
 )
 
-// SaveConfig saves the configuration to ~/.gbx/config.yaml
-func SaveConfig(config *models.Config) error {
+// defaultProfile is the profile used when none is selected
+const defaultProfile = "default"
+
+// profileFlag holds the value of the global --profile flag
+var profileFlag string
+
+// Define the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage gbx configuration and profiles",
+	Long:  `Manage the gbx configuration file and the named profiles it holds, one per account or environment.`,
+}
+
+// Define the view subcommand
+var configViewCmd = &cobra.Command{
+	Use:   "view",
+	Short: "Show the settings of the active profile",
+	Long:  `Show the settings of the active profile as YAML. The API key is redacted unless --show-secrets is given.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigView(cmd, args)
+	},
+}
+
+// Define the get subcommand
+var configGetCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a setting of the active profile",
+	Long:  `Print a setting of the active profile, e.g. 'gbx config get api_url'. The API key is redacted unless --show-secrets is given.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigGet(cmd, args)
+	},
+}
+
+// Define the set subcommand
+var configSetCmd = &cobra.Command{
+	Use:   "set KEY VALUE",
+	Short: "Change a setting of the active profile",
+	Long: `Change a setting of the active profile, e.g. 'gbx config set max_retries 5'.
+
+Available keys: api_key, account_id, plan.name, plan.region, number_of_targets,
+credential_store (plaintext or encrypted), credential_helper (a helper name, a path or
+!shell-command), api_url, max_retries, retry_timeout, proxy_url, ca_bundles (comma-separated),
+client_cert, client_key and tls_min_version.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigSet(cmd, args)
+	},
+}
+
+// Define the unset subcommand
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset KEY",
+	Short: "Remove a setting from the active profile",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigUnset(cmd, args)
+	},
+}
+
+// Define the path subcommand
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the configuration file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, configFile, err := configPaths()
+		if err != nil {
+			return err
+		}
+		fmt.Println(configFile)
+		return nil
+	},
+}
+
+// Define the use-profile subcommand
+var configUseProfileCmd = &cobra.Command{
+	Use:   "use-profile NAME",
+	Short: "Set the profile used by default",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigUseProfile(cmd, args)
+	},
+}
+
+// Define the list-profiles subcommand
+var configListProfilesCmd = &cobra.Command{
+	Use:   "list-profiles",
+	Short: "List the profiles in the configuration file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigListProfiles(cmd, args)
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configPathCmd)
+	configCmd.AddCommand(configUseProfileCmd)
+	configCmd.AddCommand(configListProfilesCmd)
+
+	configViewCmd.Flags().Bool("show-secrets", false, "Show the API key instead of redacting it")
+	configGetCmd.Flags().Bool("show-secrets", false, "Show the API key instead of redacting it")
+}
+
+// runConfigView handles the 'config view' command
+func runConfigView(cmd *cobra.Command, args []string) error {
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	name := activeProfile(file)
+	config, exists := file.Profiles[name]
+	if !exists {
+		return fmt.Errorf("profile %q not found in config file", name)
+	}
+
+	shown := *config
+	if !showSecrets && shown.APIKey != "" {
+		shown.APIKey = redactSecret(shown.APIKey)
+	}
+
+	data, err := yaml.Marshal(&shown)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config to YAML: %v", err)
+	}
+
+	fmt.Printf("# profile: %s\n%s", name, data)
+	return nil
+}
+
+// runConfigGet handles the 'config get' command
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	showSecrets, _ := cmd.Flags().GetBool("show-secrets")
+
+	key, err := lookupConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+
+	value := key.get(config)
+	if key.name == "api_key" {
+		file, err := loadConfigFile()
+		if err != nil {
+			return err
+		}
+		if value, err = profileAPIKey(activeProfile(file), config); err != nil {
+			return err
+		}
+	}
+	if key.secret && !showSecrets {
+		value = redactSecret(value)
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// runConfigSet handles the 'config set' command
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	key, err := lookupConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	return updateProfile(key, func(config *models.Config) error {
+		return key.set(config, strings.TrimSpace(args[1]))
+	})
+}
+
+// runConfigUnset handles the 'config unset' command
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	key, err := lookupConfigKey(args[0])
+	if err != nil {
+		return err
+	}
+
+	return updateProfile(key, func(config *models.Config) error {
+		key.unset(config)
+		return nil
+	})
+}
+
+// updateProfile applies a change of key to the active profile, creating it
+// if needed, and saves the configuration file. Changing where credentials are
+// kept moves the API key from the old credential store to the new one.
+func updateProfile(key *configKey, change func(config *models.Config) error) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	name := activeProfile(file)
+	config, exists := file.Profiles[name]
+	if !exists {
+		config = &models.Config{}
+	}
+
+	switch key.name {
+	case "api_key":
+		// Clear the old key wherever it is kept; set stores the new one
+		if err := eraseAPIKey(name, config); err != nil {
+			return err
+		}
+	case "credential_store", "credential_helper":
+		apiKey, err := profileAPIKey(name, config)
+		if err != nil {
+			return err
+		}
+		if err := eraseAPIKey(name, config); err != nil {
+			return err
+		}
+		config.APIKey = apiKey
+	}
+
+	if err := change(config); err != nil {
+		return err
+	}
+
+	if _, err := credentialStore(config); err != nil {
+		return err
+	}
+	return saveProfile(file, name, config)
+}
+
+// runConfigUseProfile handles the 'config use-profile' command
+func runConfigUseProfile(cmd *cobra.Command, args []string) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	if _, exists := file.Profiles[name]; !exists {
+		return fmt.Errorf("profile %q not found, run 'gbx config list-profiles' to see the available profiles", name)
+	}
+
+	file.CurrentProfile = name
+	if err := saveConfigFile(file); err != nil {
+		return err
+	}
+
+	fmt.Printf("Now using profile %q.\n", name)
+	return nil
+}
+
+// runConfigListProfiles handles the 'config list-profiles' command
+func runConfigListProfiles(cmd *cobra.Command, args []string) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	if len(file.Profiles) == 0 {
+		fmt.Println("No profiles found. Run 'gbx sign-up' to create one.")
+		return nil
+	}
+
+	names := make([]string, 0, len(file.Profiles))
+	for name := range file.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	current := activeProfile(file)
+	currentStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#D3D3D3"))
+	for _, name := range names {
+		profile := file.Profiles[name]
+		line := fmt.Sprintf("  %-20s account: %-16s plan: %s", name, valueOrNone(profile.AccountID), valueOrNone(profile.Plan.Name))
+		if name == current {
+			line = currentStyle.Render("* " + line[2:])
+		}
+		fmt.Println(line)
+	}
+	return nil
+}
+
+// valueOrNone returns s, or "-" when it is empty
+func valueOrNone(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// configPaths returns the configuration directory and file. GBX_CONFIG
+// names the file explicitly. Otherwise an existing file is used from
+// $XDG_CONFIG_HOME/gbx (~/.config/gbx when unset) or from the legacy ~/.gbx,
+// in that order. A new file is created in the XDG directory, unless the
+// legacy ~/.gbx directory already exists.
+func configPaths() (string, string, error) {
+	if configFile := os.Getenv("GBX_CONFIG"); configFile != "" {
+		return filepath.Dir(configFile), configFile, nil
+	}
+
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("unable to determine home directory: %v", err)
+		return "", "", fmt.Errorf("unable to determine home directory: %v", err)
 	}
 
-	configDir := filepath.Join(homeDir, ".gbx")
-	configFile := filepath.Join(configDir, "config.yaml")
+	xdgHome := os.Getenv("XDG_CONFIG_HOME")
+	xdgDir := filepath.Join(homeDir, ".config", "gbx")
+	if xdgHome != "" {
+		xdgDir = filepath.Join(xdgHome, "gbx")
+	}
+	legacyDir := filepath.Join(homeDir, ".gbx")
+
+	configDir := xdgDir
+	if !fileExists(filepath.Join(xdgDir, "config.yaml")) && fileExists(legacyDir) {
+		configDir = legacyDir
+	}
+	return configDir, filepath.Join(configDir, "config.yaml"), nil
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// activeProfile returns the selected profile: the --profile flag, then the
+// GBX_PROFILE environment variable, then the current profile of the file
+func activeProfile(file *models.ConfigFile) string {
+	if profileFlag != "" {
+		return profileFlag
+	}
+	if env := os.Getenv("GBX_PROFILE"); env != "" {
+		return env
+	}
+	if file != nil && file.CurrentProfile != "" {
+		return file.CurrentProfile
+	}
+	return defaultProfile
+}
+
+// profileSelected reports whether a profile was chosen explicitly with --profile or GBX_PROFILE
+func profileSelected() bool {
+	return profileFlag != "" || os.Getenv("GBX_PROFILE") != ""
+}
+
+// loadConfigFile reads the configuration file, upgrading it first if it was
+// written by an older gbx. A missing file yields an empty one.
+func loadConfigFile() (*models.ConfigFile, error) {
+	_, configFile, err := configPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &models.ConfigFile{}
+	data, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	if data, err = migrateConfig(configFile, data); err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+
+	return file, nil
+}
+
+// saveConfigFile writes the configuration file with 0600 permissions
+func saveConfigFile(file *models.ConfigFile) error {
+	configDir, configFile, err := configPaths()
+	if err != nil {
+		return err
+	}
 
 	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if err := os.Mkdir(configDir, 0700); err != nil {
+		if err := os.MkdirAll(configDir, 0700); err != nil {
 			return fmt.Errorf("failed to create config directory: %v", err)
 		}
 	}
 
-	data, err := yaml.Marshal(config)
+	file.Version = configVersion
+	data, err := yaml.Marshal(file)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config to YAML: %v", err)
 	}
 
-	if err := os.WriteFile(configFile, data, 0600); err != nil {
-		return fmt.Errorf("failed to write config file: %v", err)
+	return writeFileAtomic(configFile, data)
+}
+
+// writeFileAtomic writes a private temporary file and renames it over path,
+// so the file keeps 0600 permissions and is never left half written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
 	}
 
 	return nil
 }
+
+// backupFile copies path to path.<timestamp>.bak with 0600 permissions and
+// returns the name of the copy
+func backupFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+
+	backup := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %v", path, err)
+	}
+	return backup, nil
+}
+
+// LoadConfig reads the active profile from the configuration file. A missing
+// file or profile yields an empty configuration.
+func LoadConfig() (*models.Config, error) {
+	file, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	if config, exists := file.Profiles[activeProfile(file)]; exists {
+		return config, nil
+	}
+	return &models.Config{}, nil
+}
+
+// SaveConfig saves the configuration as the active profile in the configuration file
+func SaveConfig(config *models.Config) error {
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	return saveProfile(file, activeProfile(file), config)
+}
+
+// saveProfile stores config under the given profile name and writes the file.
+// An API key is moved to the profile's credential store, if it has one. The
+// first profile saved becomes the current one.
+func saveProfile(file *models.ConfigFile, name string, config *models.Config) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("profile name cannot be empty")
+	}
+
+	if err := storeAPIKey(name, config); err != nil {
+		return err
+	}
+
+	if file.Profiles == nil {
+		file.Profiles = map[string]*models.Config{}
+	}
+	file.Profiles[name] = config
+	if file.CurrentProfile == "" {
+		file.CurrentProfile = name
+	}
+
+	return saveConfigFile(file)
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "catalog.json")
+	if err := writeFileAtomic(path, []byte("{}")); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permissions = %o, want 600", perm)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary files left: %v", entries)
+	}
+
+	// Errors name the file, which is not always the config file
+	missing := filepath.Join(dir, "missing", "signup.json")
+	if err := writeFileAtomic(missing, []byte("{}")); err == nil || !strings.Contains(err.Error(), "failed to write "+missing) {
+		t.Errorf("writeFileAtomic() error = %v, want it to name %s", err, missing)
+	}
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+// configKey is a setting of a profile that can be read and changed with
+// 'gbx config get|set|unset'
+type configKey struct {
+	name   string
+	secret bool
+	get    func(c *models.Config) string
+	set    func(c *models.Config, value string) error
+	unset  func(c *models.Config)
+}
+
+// configKeys lists the settings in the order they are documented
+var configKeys = []configKey{
+	{
+		name:   "api_key",
+		secret: true,
+		get:    func(c *models.Config) string { return c.APIKey },
+		set:    func(c *models.Config, v string) error { c.APIKey = v; return nil },
+		unset:  func(c *models.Config) { c.APIKey = "" },
+	},
+	{
+		name:  "account_id",
+		get:   func(c *models.Config) string { return c.AccountID },
+		set:   func(c *models.Config, v string) error { c.AccountID = v; return nil },
+		unset: func(c *models.Config) { c.AccountID = "" },
+	},
+	{
+		name: "plan.name",
+		get:  func(c *models.Config) string { return c.Plan.Name },
+		set: func(c *models.Config, v string) error {
+			if err := validatePlanName(v); err != nil {
+				return err
+			}
+			c.Plan.Name = v
+			return nil
+		},
+		unset: func(c *models.Config) { c.Plan.Name = "" },
+	},
+	{
+		name: "plan.region",
+		get:  func(c *models.Config) string { return c.Plan.Region },
+		set: func(c *models.Config, v string) error {
+			if err := validateRegion(v); err != nil {
+				return err
+			}
+			c.Plan.Region = v
+			return nil
+		},
+		unset: func(c *models.Config) { c.Plan.Region = "" },
+	},
+	{
+		name: "number_of_targets",
+		get:  func(c *models.Config) string { return intOrEmpty(c.NumberOfTargets) },
+		set: func(c *models.Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return fmt.Errorf("number_of_targets must be a positive integer")
+			}
+			c.NumberOfTargets = n
+			return nil
+		},
+		unset: func(c *models.Config) { c.NumberOfTargets = 0 },
+	},
+	{
+		name: "credential_store",
+		get:  func(c *models.Config) string { return c.CredentialStore },
+		set: func(c *models.Config, v string) error {
+			if v != "plaintext" && v != "encrypted" {
+				return fmt.Errorf("credential_store must be plaintext or encrypted")
+			}
+			c.CredentialStore = v
+			return nil
+		},
+		unset: func(c *models.Config) { c.CredentialStore = "" },
+	},
+	{
+		name:  "credential_helper",
+		get:   func(c *models.Config) string { return c.CredentialHelper },
+		set:   func(c *models.Config, v string) error { c.CredentialHelper = v; return nil },
+		unset: func(c *models.Config) { c.CredentialHelper = "" },
+	},
+	{
+		name: "api_url",
+		get:  func(c *models.Config) string { return c.APIURL },
+		set: func(c *models.Config, v string) error {
+			u, err := url.Parse(v)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("api_url must be an http:// or https:// URL")
+			}
+			c.APIURL = v
+			return nil
+		},
+		unset: func(c *models.Config) { c.APIURL = "" },
+	},
+	{
+		name: "max_retries",
+		get: func(c *models.Config) string {
+			if c.MaxRetries == nil {
+				return ""
+			}
+			return strconv.Itoa(*c.MaxRetries)
+		},
+		set: func(c *models.Config, v string) error {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return fmt.Errorf("max_retries must be a non-negative integer")
+			}
+			c.MaxRetries = &n
+			return nil
+		},
+		unset: func(c *models.Config) { c.MaxRetries = nil },
+	},
+	{
+		name: "retry_timeout",
+		get: func(c *models.Config) string {
+			if c.RetryTimeout == 0 {
+				return ""
+			}
+			return c.RetryTimeout.String()
+		},
+		set: func(c *models.Config, v string) error {
+			d, err := time.ParseDuration(v)
+			if err != nil || d <= 0 {
+				return fmt.Errorf("retry_timeout must be a positive duration, e.g. 30s or 2m")
+			}
+			c.RetryTimeout = d
+			return nil
+		},
+		unset: func(c *models.Config) { c.RetryTimeout = 0 },
+	},
+	{
+		name: "proxy_url",
+		get:  func(c *models.Config) string { return c.ProxyURL },
+		set: func(c *models.Config, v string) error {
+			u, err := url.Parse(v)
+			if err != nil || u.Host == "" {
+				return fmt.Errorf("proxy_url must be a URL, e.g. http://proxy.internal:3128")
+			}
+			c.ProxyURL = v
+			return nil
+		},
+		unset: func(c *models.Config) { c.ProxyURL = "" },
+	},
+	{
+		name: "ca_bundles",
+		get:  func(c *models.Config) string { return strings.Join(c.CABundles, ",") },
+		set: func(c *models.Config, v string) error {
+			var bundles []string
+			for _, bundle := range strings.Split(v, ",") {
+				if bundle = strings.TrimSpace(bundle); bundle == "" {
+					continue
+				}
+				if _, err := os.Stat(bundle); err != nil {
+					return fmt.Errorf("CA bundle %s: %v", bundle, err)
+				}
+				bundles = append(bundles, bundle)
+			}
+			if len(bundles) == 0 {
+				return fmt.Errorf("ca_bundles must list at least one PEM file")
+			}
+			c.CABundles = bundles
+			return nil
+		},
+		unset: func(c *models.Config) { c.CABundles = nil },
+	},
+	{
+		name:  "client_cert",
+		get:   func(c *models.Config) string { return c.ClientCert },
+		set:   func(c *models.Config, v string) error { c.ClientCert = v; return nil },
+		unset: func(c *models.Config) { c.ClientCert = "" },
+	},
+	{
+		name:  "client_key",
+		get:   func(c *models.Config) string { return c.ClientKey },
+		set:   func(c *models.Config, v string) error { c.ClientKey = v; return nil },
+		unset: func(c *models.Config) { c.ClientKey = "" },
+	},
+	{
+		name: "tls_min_version",
+		get:  func(c *models.Config) string { return c.TLSMinVersion },
+		set: func(c *models.Config, v string) error {
+			if _, err := client.ParseTLSVersion(v); err != nil {
+				return err
+			}
+			c.TLSMinVersion = v
+			return nil
+		},
+		unset: func(c *models.Config) { c.TLSMinVersion = "" },
+	},
+}
+
+// lookupConfigKey returns the setting with the given name
+func lookupConfigKey(name string) (*configKey, error) {
+	for i := range configKeys {
+		if configKeys[i].name == name {
+			return &configKeys[i], nil
+		}
+	}
+
+	names := make([]string, len(configKeys))
+	for i, key := range configKeys {
+		names[i] = key.name
+	}
+	return nil, fmt.Errorf("unknown config key %q, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// redactSecret hides all but the last four characters of a secret
+func redactSecret(secret string) string {
+	if len(secret) <= 8 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", 8) + secret[len(secret)-4:]
+}
+
+// intOrEmpty formats n, or returns an empty string for zero
+func intOrEmpty(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
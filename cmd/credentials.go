@@ -0,0 +1,239 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+
+	"globalblackbox.io/gbx/credentials"
+	"globalblackbox.io/gbx/models"
+)
+
+// apiKeyFile holds the value of the global --api-key-file flag
+var apiKeyFile string
+
+// resolvedCredentials are the API key and account ID in use, and where the key came from
+type resolvedCredentials struct {
+	APIKey    string
+	AccountID string
+	Source    string
+}
+
+// resolveCredentials finds the credentials to use. The API key comes from,
+// in order: the --api-key-file flag, the GBX_API_KEY environment variable,
+// and the active profile of the configuration file. GBX_ACCOUNT_ID likewise
+// overrides the account ID of the profile. No configuration file is needed
+// when the key is given by the flag or the environment.
+func resolveCredentials() (*resolvedCredentials, error) {
+	creds, err := environmentCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	if creds.APIKey != "" && creds.AccountID != "" {
+		return creds, nil
+	}
+
+	_, configFile, err := configPaths()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+		if creds.APIKey != "" {
+			return creds, nil
+		}
+		return nil, fmt.Errorf("config file not found at %s, set GBX_API_KEY or run 'gbx sign-up'", configFile)
+	}
+
+	file, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return profileCredentials(creds, file)
+}
+
+// environmentCredentials returns the credentials given by --api-key-file,
+// GBX_API_KEY and GBX_ACCOUNT_ID, which may be incomplete
+func environmentCredentials() (*resolvedCredentials, error) {
+	creds := &resolvedCredentials{AccountID: os.Getenv("GBX_ACCOUNT_ID")}
+
+	switch {
+	case apiKeyFile != "":
+		data, err := os.ReadFile(apiKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read API key file: %v", err)
+		}
+		creds.APIKey = strings.TrimSpace(string(data))
+		if creds.APIKey == "" {
+			return nil, fmt.Errorf("API key file %s is empty", apiKeyFile)
+		}
+		creds.Source = apiKeyFile
+	case os.Getenv("GBX_API_KEY") != "":
+		creds.APIKey = strings.TrimSpace(os.Getenv("GBX_API_KEY"))
+		creds.Source = "GBX_API_KEY"
+	}
+
+	return creds, nil
+}
+
+// profileCredentials completes creds with the active profile of file
+func profileCredentials(creds *resolvedCredentials, file *models.ConfigFile) (*resolvedCredentials, error) {
+	name := activeProfile(file)
+	config, exists := file.Profiles[name]
+	if !exists {
+		if creds.APIKey != "" {
+			return creds, nil
+		}
+		return nil, fmt.Errorf("profile %q not found in config file", name)
+	}
+
+	if creds.AccountID == "" {
+		creds.AccountID = config.AccountID
+	}
+	if creds.APIKey == "" {
+		apiKey, err := profileAPIKey(name, config)
+		if err != nil {
+			return nil, err
+		}
+		creds.APIKey = strings.TrimSpace(apiKey)
+		creds.Source = fmt.Sprintf("profile %q", name)
+	}
+
+	if creds.APIKey == "" {
+		return nil, fmt.Errorf("API key not found in profile %q of the config file", name)
+	}
+
+	return creds, nil
+}
+
+// getAPIKey retrieves the API key, see resolveCredentials for where it is looked up
+func getAPIKey() (string, error) {
+	creds, err := resolveCredentials()
+	if err != nil {
+		return "", err
+	}
+	return creds.APIKey, nil
+}
+
+// credentialStore returns the store keeping the API key of a profile, or nil
+// when the key is kept in plaintext in the configuration file
+func credentialStore(config *models.Config) (credentials.Store, error) {
+	switch {
+	case config.CredentialHelper != "":
+		return &credentials.Helper{Command: config.CredentialHelper}, nil
+	case config.CredentialStore == "encrypted":
+		configDir, _, err := configPaths()
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(configDir, "credentials.enc")
+		return &credentials.EncryptedFile{
+			Path:       path,
+			Passphrase: func() (string, error) { return passphrase(path) },
+		}, nil
+	case config.CredentialStore == "" || config.CredentialStore == "plaintext":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown credential_store %q, expected plaintext or encrypted", config.CredentialStore)
+}
+
+// storeProfile describes a profile to a credential store
+func storeProfile(name string, config *models.Config) credentials.Profile {
+	return credentials.Profile{Name: name, AccountID: config.AccountID, APIURL: config.APIURL}
+}
+
+// profileAPIKey returns the API key of a profile, from its credential store if it has one
+func profileAPIKey(name string, config *models.Config) (string, error) {
+	if config.APIKey != "" {
+		return config.APIKey, nil
+	}
+
+	store, err := credentialStore(config)
+	if err != nil || store == nil {
+		return "", err
+	}
+
+	apiKey, err := store.Get(storeProfile(name, config))
+	if errors.Is(err, credentials.ErrNotFound) {
+		return "", nil
+	}
+	return apiKey, err
+}
+
+// storeAPIKey moves the API key of a profile into its credential store, if
+// it has one, so that it is not written to the configuration file
+func storeAPIKey(name string, config *models.Config) error {
+	if config.APIKey == "" {
+		return nil
+	}
+
+	store, err := credentialStore(config)
+	if err != nil || store == nil {
+		return err
+	}
+
+	if err := store.Store(storeProfile(name, config), config.APIKey); err != nil {
+		return err
+	}
+	config.APIKey = ""
+	return nil
+}
+
+// eraseAPIKey removes the API key of a profile from its credential store and the configuration
+func eraseAPIKey(name string, config *models.Config) error {
+	config.APIKey = ""
+
+	store, err := credentialStore(config)
+	if err != nil || store == nil {
+		return err
+	}
+	return store.Erase(storeProfile(name, config))
+}
+
+// cachedPassphrase avoids asking for the passphrase more than once per run
+var cachedPassphrase string
+
+// passphrase returns the passphrase of the encrypted credentials file, from
+// the GBX_PASSPHRASE environment variable or by prompting for it. A new file
+// asks for the passphrase twice.
+func passphrase(path string) (string, error) {
+	if env := os.Getenv("GBX_PASSPHRASE"); env != "" {
+		return env, nil
+	}
+	if cachedPassphrase != "" {
+		return cachedPassphrase, nil
+	}
+
+	prompt := promptui.Prompt{
+		Label: "Enter the passphrase protecting your API keys",
+		Mask:  '*',
+	}
+	value, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		confirm := promptui.Prompt{
+			Label: "Confirm the passphrase",
+			Mask:  '*',
+			Validate: func(input string) error {
+				if input != value {
+					return fmt.Errorf("passphrases do not match")
+				}
+				return nil
+			},
+		}
+		if _, err := confirm.Run(); err != nil {
+			return "", err
+		}
+	}
+
+	cachedPassphrase = value
+	return value, nil
+}
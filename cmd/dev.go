@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/fakeapi"
+)
+
+// Define the hidden dev command, for tooling used while developing gbx
+var devCmd = &cobra.Command{
+	Use:    "dev",
+	Short:  "Development tools",
+	Hidden: true,
+}
+
+// Define the fake-api subcommand
+var devFakeAPICmd = &cobra.Command{
+	Use:   "fake-api",
+	Short: "Run a local fake of the Global Blackbox API",
+	Long: `Run a local, in-memory fake of the Global Blackbox API for integration tests.
+Point gbx at it with --api-url or GBX_API_URL and use the fixture API key it prints.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDevFakeAPI(cmd, args)
+	},
+}
+
+func init() {
+	devCmd.AddCommand(devFakeAPICmd)
+
+	devFakeAPICmd.Flags().String("listen", "127.0.0.1:8787", "Address to listen on")
+	devFakeAPICmd.Flags().Int("fail-status", 0, "Fail requests with this HTTP status (e.g. 401, 429, 500)")
+	devFakeAPICmd.Flags().Int("fail-times", 0, "Number of requests to fail (0 means all)")
+	devFakeAPICmd.Flags().String("fail-path", "", "Only fail requests whose path starts with this prefix")
+	devFakeAPICmd.Flags().String("retry-after", "", "Retry-After header sent with injected failures")
+	devFakeAPICmd.Flags().Duration("latency", 0, "Delay added to every response")
+}
+
+// runDevFakeAPI handles the 'dev fake-api' command
+func runDevFakeAPI(cmd *cobra.Command, args []string) error {
+	listen, _ := cmd.Flags().GetString("listen")
+	failStatus, _ := cmd.Flags().GetInt("fail-status")
+	failTimes, _ := cmd.Flags().GetInt("fail-times")
+	failPath, _ := cmd.Flags().GetString("fail-path")
+	retryAfter, _ := cmd.Flags().GetString("retry-after")
+	latency, _ := cmd.Flags().GetDuration("latency")
+
+	server := fakeapi.New()
+	if failStatus != 0 {
+		server.InjectFault(fakeapi.Fault{
+			Path:       failPath,
+			Status:     failStatus,
+			Times:      failTimes,
+			RetryAfter: retryAfter,
+		})
+	}
+	server.SetLatency(latency)
+
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", listen, err)
+	}
+
+	fmt.Printf("Fake Global Blackbox API listening on http://%s\n", listener.Addr())
+	fmt.Printf("Fixture API key: %s\n", fakeapi.FixtureAPIKey)
+	fmt.Printf("Fixture logs: --region %s --target_domain %s --date %s\n",
+		fakeapi.FixtureRegion, fakeapi.FixtureTargetDomain, fakeapi.FixtureDate)
+
+	httpServer := &http.Server{Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-cmd.Context().Done()
+		httpServer.Close()
+	}()
+
+	if err := httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
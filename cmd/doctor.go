@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/models"
+)
+
+// Clock skew above which the doctor warns, and above which it fails
+const (
+	clockSkewWarning = 30 * time.Second
+	clockSkewFailure = 5 * time.Minute
+)
+
+// doctorTimeout bounds each network check of the doctor
+const doctorTimeout = 10 * time.Second
+
+// Define the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the configuration and the connection to the API",
+	Long: `Check that the configuration file exists, is private and valid, that the API key
+is present and accepted, that the plan and region are known, that the API can be
+reached, and that the local clock is accurate. Every problem comes with a hint on
+how to fix it, and gbx exits with a non-zero status when a check fails.
+
+The configuration file is only read: files written by older versions of gbx are
+reported but not upgraded.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDoctor(cmd, args)
+	},
+}
+
+// checkStatus is the outcome of a doctor check
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// doctor runs the checks in order, printing each result as it completes
+type doctor struct {
+	cmd      *cobra.Command
+	counts   map[checkStatus]int
+	file     *models.ConfigFile
+	config   *models.Config
+	creds    *resolvedCredentials
+	apiURL   string
+	reached  bool
+	serverAt time.Time
+}
+
+// report prints the result of a check and, for problems, how to fix them
+func (d *doctor) report(status checkStatus, check, detail, hint string) {
+	d.counts[status]++
+
+	labels := map[checkStatus]string{checkPass: "PASS", checkWarn: "WARN", checkFail: "FAIL"}
+	colors := map[checkStatus]string{checkPass: "#D3D3D3", checkWarn: "#696969", checkFail: "1"}
+	labelStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color(colors[status]))
+	checkStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#A9A9A9"))
+
+	fmt.Printf("%s %s: %s\n", labelStyle.Render(labels[status]), checkStyle.Render(check), detail)
+	if hint != "" && status != checkPass {
+		fmt.Printf("     %s\n", hint)
+	}
+}
+
+// runDoctor handles the 'doctor' command
+func runDoctor(cmd *cobra.Command, args []string) error {
+	// Nothing is prompted for, so --timeout bounds the whole run
+	ctx, end := networkTime.begin(cmd.Context())
+	defer end()
+	cmd.SetContext(ctx)
+
+	d := &doctor{cmd: cmd, counts: map[checkStatus]int{}, config: &models.Config{}}
+
+	d.checkConfigFile()
+	d.checkCatalog()
+	d.checkCredentials()
+	d.checkNetwork()
+	d.checkClock()
+	d.checkAPIKey()
+
+	fmt.Println()
+	fmt.Printf("%d passed, %d warning(s), %d failed\n", d.counts[checkPass], d.counts[checkWarn], d.counts[checkFail])
+	if d.counts[checkFail] > 0 {
+		return fmt.Errorf("%d check(s) failed", d.counts[checkFail])
+	}
+	return nil
+}
+
+// checkConfigFile checks that the configuration file exists, is private and
+// parses, and selects the active profile without upgrading the file
+func (d *doctor) checkConfigFile() {
+	configDir, configFile, err := configPaths()
+	if err != nil {
+		d.report(checkFail, "Config file", err.Error(), "Set GBX_CONFIG to the path of the configuration file.")
+		return
+	}
+
+	data, err := os.ReadFile(configFile)
+	switch {
+	case os.IsNotExist(err) && (apiKeyFile != "" || os.Getenv("GBX_API_KEY") != ""):
+		d.report(checkPass, "Config file", fmt.Sprintf("not found at %s, credentials come from the environment", configFile), "")
+		return
+	case os.IsNotExist(err):
+		d.report(checkFail, "Config file", fmt.Sprintf("not found at %s", configFile),
+			"Run 'gbx sign-up' to create an account or 'gbx auth login' to use an existing API key.")
+		return
+	case err != nil:
+		d.report(checkFail, "Config file", err.Error(), fmt.Sprintf("Make sure %s is readable by your user.", configFile))
+		return
+	}
+	d.report(checkPass, "Config file", configFile, "")
+
+	d.checkPermissions(configDir, configFile)
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		d.report(checkFail, "Config syntax", fmt.Sprintf("invalid YAML: %v", err),
+			fmt.Sprintf("Fix the file by hand, or move it away and run 'gbx auth login': %s", configFile))
+		return
+	}
+	if doc == nil {
+		doc = map[string]interface{}{}
+	}
+
+	version, err := upgradeConfigDoc(doc)
+	if err != nil {
+		d.report(checkFail, "Config syntax", err.Error(), "Upgrade gbx, or fix the version field of the file.")
+		return
+	}
+
+	upgraded, err := yaml.Marshal(doc)
+	if err == nil {
+		d.file = &models.ConfigFile{}
+		err = yaml.Unmarshal(upgraded, d.file)
+	}
+	if err != nil {
+		d.file = nil
+		d.report(checkFail, "Config syntax", fmt.Sprintf("unexpected content: %v", err),
+			"Compare the file with the example in the README, or check a single value with 'gbx config get KEY'.")
+		return
+	}
+
+	if version < configVersion {
+		d.report(checkWarn, "Config syntax", fmt.Sprintf("valid, but written with schema version %d", version),
+			"Run any gbx command, e.g. 'gbx config view', to upgrade it; a backup of the original is kept.")
+	} else {
+		d.report(checkPass, "Config syntax", fmt.Sprintf("valid, schema version %d", version), "")
+	}
+
+	name := activeProfile(d.file)
+	config, exists := d.file.Profiles[name]
+	switch {
+	case exists:
+		d.config = config
+		d.report(checkPass, "Profile", fmt.Sprintf("%q", name), "")
+	case profileSelected():
+		d.report(checkFail, "Profile", fmt.Sprintf("%q not found in config file", name),
+			"Run 'gbx config list-profiles' to see the available profiles.")
+	default:
+		d.report(checkWarn, "Profile", fmt.Sprintf("%q not found in config file", name),
+			"Run 'gbx config use-profile NAME' to select one of the profiles shown by 'gbx config list-profiles'.")
+	}
+}
+
+// checkPermissions checks that the configuration is only accessible by its owner
+func (d *doctor) checkPermissions(configDir, configFile string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	files := []string{configFile}
+	if credentialsFile := filepath.Join(configDir, "credentials.enc"); fileExists(credentialsFile) {
+		files = append(files, credentialsFile)
+	}
+
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			d.report(checkFail, "Permissions", err.Error(), "")
+			return
+		}
+		if perm := info.Mode().Perm(); perm&0077 != 0 {
+			d.report(checkFail, "Permissions", fmt.Sprintf("%s is accessible by other users (%04o)", path, perm),
+				fmt.Sprintf("Run 'chmod 600 %s'.", path))
+			return
+		}
+	}
+
+	if info, err := os.Stat(configDir); err == nil && info.Mode().Perm()&0077 != 0 {
+		d.report(checkWarn, "Permissions", fmt.Sprintf("%s is accessible by other users (%04o)", configDir, info.Mode().Perm()),
+			fmt.Sprintf("Run 'chmod 700 %s'.", configDir))
+		return
+	}
+
+	d.report(checkPass, "Permissions", "only accessible by you", "")
+}
+
+// checkCatalog checks that the plan and region of the profile are known to gbx
+func (d *doctor) checkCatalog() {
+	if d.file == nil || (d.config.AccountID == "" && d.config.Plan.Name == "") {
+		return
+	}
+
+	plan := d.config.Plan
+	var planErr, regionErr error
+	if plan.Name != "" {
+		planErr = validatePlanName(plan.Name)
+	}
+	if plan.Region != "" {
+		regionErr = validateRegion(plan.Region)
+	}
+
+	switch {
+	case plan.Name == "":
+		d.report(checkWarn, "Plan", "no plan recorded in the profile",
+			"Run 'gbx auth login' to fetch the plan of your account.")
+		return
+	case planErr != nil:
+		d.report(checkFail, "Plan", planErr.Error(),
+			"Run 'gbx config set plan.name NAME' with one of the plans above.")
+		return
+	case regionErr != nil:
+		d.report(checkFail, "Plan", regionErr.Error(),
+			"Run 'gbx config set plan.region REGION' with a known region code.")
+		return
+	case planChoosesRegion(plan.Name) && plan.Region == "":
+		d.report(checkWarn, "Plan", "single-region plan without a region",
+			"Run 'gbx config set plan.region REGION'.")
+		return
+	}
+
+	detail := plan.Name
+	if plan.Region != "" {
+		detail += " in " + plan.Region
+	}
+	d.report(checkPass, "Plan", detail, "")
+}
+
+// checkCredentials checks that an API key is available
+func (d *doctor) checkCredentials() {
+	creds, err := environmentCredentials()
+	if err == nil && creds.APIKey == "" && d.file != nil {
+		creds, err = profileCredentials(creds, d.file)
+	}
+	switch {
+	case err != nil:
+		d.report(checkFail, "API key", err.Error(), "Run 'gbx auth login' to save your API key.")
+		return
+	case creds.APIKey == "":
+		d.report(checkFail, "API key", "not found", "Run 'gbx auth login' or set GBX_API_KEY.")
+		return
+	}
+
+	d.creds = creds
+	d.report(checkPass, "API key", fmt.Sprintf("%s from %s", redactSecret(creds.APIKey), creds.Source), "")
+}
+
+// checkNetwork checks that the API host resolves and accepts TLS connections
+func (d *doctor) checkNetwork() {
+	apiURL, err := resolveAPIURL(d.cmd, d.config)
+	if err != nil {
+		d.report(checkFail, "API URL", err.Error(), "Fix --api-url, GBX_API_URL or api_url in the config file.")
+		return
+	}
+	d.apiURL = apiURL
+	u, _ := url.Parse(apiURL)
+
+	cfg := transportConfig(d.cmd, d.config)
+	if cfg.ProxyURL != "" {
+		d.report(checkPass, "DNS", fmt.Sprintf("%s is resolved by the proxy %s", u.Hostname(), cfg.ProxyURL), "")
+	} else {
+		ctx, cancel := context.WithTimeout(d.cmd.Context(), doctorTimeout)
+		addrs, err := net.DefaultResolver.LookupHost(ctx, u.Hostname())
+		cancel()
+		if err != nil {
+			d.report(checkFail, "DNS", fmt.Sprintf("failed to resolve %s: %v", u.Hostname(), err),
+				"Check your network and DNS settings, or set --proxy if you are behind a proxy.")
+			return
+		}
+		d.report(checkPass, "DNS", fmt.Sprintf("%s resolves to %s", u.Hostname(), addrs[0]), "")
+	}
+
+	transport, err := client.NewTransport(cfg)
+	if err != nil {
+		d.report(checkFail, "Connection", err.Error(), "Fix the proxy and TLS settings, see 'gbx --help'.")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(d.cmd.Context(), doctorTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, apiURL, nil)
+	if err != nil {
+		d.report(checkFail, "Connection", err.Error(), "")
+		return
+	}
+	req.Header.Set("User-Agent", userAgent())
+
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		d.report(checkFail, "Connection", fmt.Sprintf("failed to reach %s: %v", u.Host, err), connectionHint(err))
+		return
+	}
+	resp.Body.Close()
+	d.reached = true
+
+	if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		d.serverAt = date
+	}
+
+	if resp.TLS == nil {
+		d.report(checkWarn, "Connection", fmt.Sprintf("%s reachable without TLS", u.Host),
+			"Use an https:// API URL outside of local testing.")
+		return
+	}
+	d.report(checkPass, "Connection", fmt.Sprintf("%s reachable over %s", u.Host, tls.VersionName(resp.TLS.Version)), "")
+}
+
+// connectionHint suggests a fix for an error connecting to the API
+func connectionHint(err error) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "The certificate is not trusted: if a proxy inspects TLS traffic, pass its CA with --ca-bundle."
+	case errors.As(err, &invalidCert), errors.As(err, &hostnameErr):
+		return "The certificate is invalid for this host: check --api-url and that your clock is correct."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "The API did not answer in time: check your firewall, or set --proxy if you are behind a proxy."
+	}
+	return "Check your network and firewall, or set --proxy if you are behind a proxy."
+}
+
+// checkClock compares the local clock with the Date header of the API
+func (d *doctor) checkClock() {
+	if !d.reached {
+		return
+	}
+	if d.serverAt.IsZero() {
+		d.report(checkWarn, "Clock", "the API did not report its time", "")
+		return
+	}
+
+	skew := time.Since(d.serverAt).Round(time.Second)
+	if skew < 0 {
+		skew = -skew
+	}
+	switch {
+	case skew > clockSkewFailure:
+		d.report(checkFail, "Clock", fmt.Sprintf("off by %s", skew),
+			"Synchronize your clock, e.g. enable NTP; TLS and dates of log files depend on it.")
+	case skew > clockSkewWarning:
+		d.report(checkWarn, "Clock", fmt.Sprintf("off by %s", skew), "Synchronize your clock, e.g. enable NTP.")
+	default:
+		d.report(checkPass, "Clock", fmt.Sprintf("in sync with the API (within %s)", clockSkewWarning), "")
+	}
+}
+
+// checkAPIKey checks that the API accepts the API key
+func (d *doctor) checkAPIKey() {
+	if d.creds == nil || !d.reached {
+		return
+	}
+
+	transport, err := client.NewTransport(transportConfig(d.cmd, d.config))
+	if err != nil {
+		return
+	}
+
+	// No retries: the doctor reports the first answer of the API
+	var base http.RoundTripper = transport
+	if debugEnabled(d.cmd) {
+		base = &client.DebugTransport{Base: transport, Out: os.Stderr}
+	}
+	apiClient := client.New(
+		client.WithBaseURL(d.apiURL),
+		client.WithAPIKey(d.creds.APIKey),
+		client.WithHTTPClient(&http.Client{Transport: base}),
+		client.WithUserAgent(userAgent()),
+		client.WithCompatibilityHandler(warnCompatibility),
+	)
+
+	ctx, cancel := context.WithTimeout(d.cmd.Context(), doctorTimeout)
+	defer cancel()
+	account, err := apiClient.GetAccount(ctx)
+	switch {
+	case errors.Is(err, client.ErrUnauthorized):
+		d.report(checkFail, "Authentication", "the API key was rejected",
+			"Run 'gbx auth login' with a valid API key.")
+		return
+	case err != nil:
+		d.report(checkFail, "Authentication", err.Error(), "Try again later, or run 'gbx doctor --debug' for details.")
+		return
+	}
+
+	if d.creds.AccountID != "" && d.creds.AccountID != account.AccountID {
+		d.report(checkWarn, "Authentication", fmt.Sprintf("the API key belongs to account %s, not %s", account.AccountID, d.creds.AccountID),
+			"Run 'gbx auth login' to update the account of the profile.")
+		return
+	}
+	d.report(checkPass, "Authentication", fmt.Sprintf("account %s (%s)", account.AccountID, account.Status), "")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+
+	"github.com/manifoldco/promptui"
+
+	"globalblackbox.io/gbx/client"
+)
+
+// Process exit codes, documented in the README
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitUnauthorized  = 3
+	exitNotFound      = 4
+	exitQuotaExceeded = 5
+	exitRateLimited   = 6
+	exitServerError   = 7
+	exitTimeout       = 124
+	exitInterrupted   = 130
+)
+
+// exitCodeFor maps an error returned by a command to the process exit code
+func exitCodeFor(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, context.Canceled), errors.Is(err, promptui.ErrInterrupt):
+		return exitInterrupted
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitTimeout
+	case errors.Is(err, client.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, client.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, client.ErrQuotaExceeded):
+		return exitQuotaExceeded
+	case errors.Is(err, client.ErrRateLimited):
+		return exitRateLimited
+	case errors.Is(err, client.ErrServer):
+		return exitServerError
+	}
+	return exitError
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/manifoldco/promptui"
+
+	"globalblackbox.io/gbx/client"
+)
+
+func TestExitCodeFor(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitOK},
+		{errors.New("invalid flag"), exitError},
+		{&client.APIError{StatusCode: http.StatusBadRequest}, exitError},
+		{&client.APIError{StatusCode: http.StatusUnauthorized}, exitUnauthorized},
+		{&client.APIError{StatusCode: http.StatusNotFound}, exitNotFound},
+		{&client.APIError{StatusCode: http.StatusPaymentRequired}, exitQuotaExceeded},
+		{&client.APIError{StatusCode: http.StatusTooManyRequests}, exitRateLimited},
+		{&client.APIError{StatusCode: http.StatusServiceUnavailable}, exitServerError},
+		{fmt.Errorf("plan change failed: %w", &client.APIError{StatusCode: http.StatusNotFound}), exitNotFound},
+		{context.DeadlineExceeded, exitTimeout},
+		{fmt.Errorf("download failed: %w", context.Canceled), exitInterrupted},
+		{promptui.ErrInterrupt, exitInterrupted},
+	}
+	for _, tt := range tests {
+		if got := exitCodeFor(tt.err); got != tt.want {
+			t.Errorf("exitCodeFor(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -1,18 +1,14 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
 
 	"globalblackbox.io/gbx/models"
 )
@@ -29,8 +25,8 @@ var logsListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available log files",
 	Long:  `List available log files based on region, target domain, and date.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		runLogsList(cmd, args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogsList(cmd, args)
 	},
 }
 
@@ -39,15 +35,11 @@ var logsDownloadCmd = &cobra.Command{
 	Use:   "download",
 	Short: "Download a specific log file",
 	Long:  `Download a specific log file by providing the file name, region, target domain, and date.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		runLogsDownload(cmd, args)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLogsDownload(cmd, args)
 	},
 }
 
-var (
-	API_BASE_URL = "https://api.globalblackbox.io"
-)
-
 func init() {
 	// Add list and download as subcommands of logs
 	logsCmd.AddCommand(logsListCmd)
@@ -72,14 +64,17 @@ func init() {
 }
 
 // runLogsList handles the 'logs list' command
-func runLogsList(cmd *cobra.Command, args []string) {
+func runLogsList(cmd *cobra.Command, args []string) error {
 	region, _ := cmd.Flags().GetString("region")
 	targetDomain, _ := cmd.Flags().GetString("target_domain")
 	date, _ := cmd.Flags().GetString("date")
 	limit, _ := cmd.Flags().GetInt("limit")
 
+	if err := validateRegion(region); err != nil {
+		return err
+	}
 	if err := validateDate(date); err != nil {
-		exitWithError(err)
+		return err
 	}
 
 	if limit > 50 {
@@ -89,43 +84,27 @@ func runLogsList(cmd *cobra.Command, args []string) {
 
 	apiKey, err := getAPIKey()
 	if err != nil {
-		exitWithError(err)
+		return err
 	}
 
-	url := fmt.Sprintf("%s/logs?region=%s&target_domain=%s&date=%s&limit=%d",
-		API_BASE_URL, region, targetDomain, date, limit)
-
-	req, err := http.NewRequest("GET", url, nil)
+	apiClient, err := newAPIClient(cmd, apiKey)
 	if err != nil {
-		exitWithError(fmt.Errorf("failed to create HTTP request: %v", err))
+		return err
 	}
 
-	req.Header.Add("x-api-key", apiKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	logsResponse, err := apiClient.ListLogs(cmd.Context(), models.LogsQuery{
+		Region:       region,
+		TargetDomain: targetDomain,
+		Date:         date,
+		Limit:        limit,
+	})
 	if err != nil {
-		exitWithError(fmt.Errorf("failed to execute HTTP request: %v", err))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		json.Unmarshal(bodyBytes, &errorResp)
-		exitWithError(fmt.Errorf("API request failed with status %s: %v", resp.Status, errorResp))
-	}
-
-	var logsResponse struct {
-		LogFiles []string `json:"logs"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&logsResponse); err != nil {
-		exitWithError(fmt.Errorf("failed to parse API response: %v", err))
+		return err
 	}
 
 	if len(logsResponse.LogFiles) == 0 {
 		fmt.Println("No log files found for the given parameters.")
-		return
+		return nil
 	}
 
 	listStyle := lipgloss.NewStyle().
@@ -137,101 +116,59 @@ func runLogsList(cmd *cobra.Command, args []string) {
 		fmt.Printf("%d. %s\n", i+1, file)
 	}
 	fmt.Println()
+	return nil
 }
 
 // runLogsDownload handles the 'logs download' command
-func runLogsDownload(cmd *cobra.Command, args []string) {
+func runLogsDownload(cmd *cobra.Command, args []string) error {
 	fileName, _ := cmd.Flags().GetString("fileName")
 	region, _ := cmd.Flags().GetString("region")
 	targetDomain, _ := cmd.Flags().GetString("target_domain")
 	date, _ := cmd.Flags().GetString("date")
 
+	if err := validateRegion(region); err != nil {
+		return err
+	}
 	if err := validateDate(date); err != nil {
-		exitWithError(err)
+		return err
 	}
 
 	apiKey, err := getAPIKey()
 	if err != nil {
-		exitWithError(err)
+		return err
 	}
 
-	url := fmt.Sprintf("%s/logs/%s?region=%s&target_domain=%s&date=%s",
-		API_BASE_URL, fileName, region, targetDomain, date)
-
-	req, err := http.NewRequest("GET", url, nil)
+	apiClient, err := newAPIClient(cmd, apiKey)
 	if err != nil {
-		exitWithError(fmt.Errorf("failed to create HTTP request: %v", err))
-	}
-
-	req.Header.Add("x-api-key", apiKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		exitWithError(fmt.Errorf("failed to execute HTTP request: %v", err))
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		json.Unmarshal(bodyBytes, &errorResp)
-		exitWithError(fmt.Errorf("API request failed with status %s: %v", resp.Status, errorResp))
+		return err
 	}
 
 	logsDir := "logs"
 	if _, err := os.Stat(logsDir); os.IsNotExist(err) {
 		if err := os.Mkdir(logsDir, 0755); err != nil {
-			exitWithError(fmt.Errorf("failed to create logs directory: %v", err))
+			return fmt.Errorf("failed to create logs directory: %v", err)
 		}
 	}
 
 	filePath := filepath.Join(logsDir, fileName)
-	file, err := os.Create(filePath)
+	err = downloadToFile(filePath, 0644, func(w io.Writer) error {
+		_, err := apiClient.DownloadLog(cmd.Context(), models.LogFile{
+			FileName:     fileName,
+			Region:       region,
+			TargetDomain: targetDomain,
+			Date:         date,
+		}, w)
+		return err
+	})
 	if err != nil {
-		exitWithError(fmt.Errorf("failed to create file: %v", err))
-	}
-	defer file.Close()
-
-	_, err = io.Copy(file, resp.Body)
-	if err != nil {
-		exitWithError(fmt.Errorf("failed to write to file: %v", err))
+		return err
 	}
 
 	downloadStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#D3D3D3"))
 	fmt.Printf("\n%s: %s has been downloaded to the '%s' directory.\n\n", downloadStyle.Render("Success"), fileName, logsDir)
-}
-
-// getAPIKey retrieves the API key from the configuration file
-func getAPIKey() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("unable to determine home directory: %v", err)
-	}
-
-	configFile := filepath.Join(homeDir, ".gbx", "config.yaml")
-
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		return "", fmt.Errorf("config file not found at %s", configFile)
-	}
-
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		return "", fmt.Errorf("failed to read config file: %v", err)
-	}
-
-	var config models.Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return "", fmt.Errorf("failed to parse config file: %v", err)
-	}
-
-	if strings.TrimSpace(config.APIKey) == "" {
-		return "", fmt.Errorf("API key not found in config file")
-	}
-
-	return config.APIKey, nil
+	return nil
 }
 
 // validateDate checks if the provided date is in YYYY-MM-DD format
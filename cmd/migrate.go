@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"gopkg.in/yaml.v2"
+)
+
+// configVersion is the configuration schema version written by this gbx.
+// Files without a version field are version 1.
+//
+//	1: a single account at the top level
+//	2: named profiles under "profiles", selected by "current_profile"
+const configVersion = 2
+
+// configMigrations upgrade a configuration document one version at a time:
+// configMigrations[i] turns version i+1 into version i+2. A new schema
+// version appends its migration here and bumps configVersion.
+var configMigrations = []func(doc map[string]interface{}) error{
+	migrateToProfiles,
+}
+
+// migrateToProfiles moves a top-level account and its settings into the
+// default profile
+func migrateToProfiles(doc map[string]interface{}) error {
+	if _, exists := doc["profiles"]; exists {
+		return nil
+	}
+
+	profile := map[string]interface{}{}
+	for key, value := range doc {
+		if key == "version" || key == "current_profile" {
+			continue
+		}
+		profile[key] = value
+		delete(doc, key)
+	}
+
+	if len(profile) > 0 {
+		doc["profiles"] = map[string]interface{}{defaultProfile: profile}
+		doc["current_profile"] = defaultProfile
+	}
+	return nil
+}
+
+// upgradeConfigDoc upgrades a parsed configuration document to configVersion
+// in memory and returns the version it was written with
+func upgradeConfigDoc(doc map[string]interface{}) (int, error) {
+	version := 1
+	if v, exists := doc["version"]; exists {
+		n, ok := v.(int)
+		if !ok || n < 1 {
+			return 0, fmt.Errorf("invalid version %v in config file", v)
+		}
+		version = n
+	}
+
+	if version > configVersion {
+		return 0, fmt.Errorf("config file version %d is newer than this gbx supports (%d), please upgrade gbx", version, configVersion)
+	}
+
+	for v := version; v < configVersion; v++ {
+		if err := configMigrations[v-1](doc); err != nil {
+			return 0, fmt.Errorf("failed to migrate config file from version %d to %d: %v", v, v+1, err)
+		}
+	}
+	doc["version"] = configVersion
+
+	return version, nil
+}
+
+// migrateConfig upgrades the configuration file content to configVersion.
+// When a migration is needed, the original file is backed up next to
+// configFile and the upgraded content is written in its place. A file that
+// cannot be written, e.g. a read-only mount, is only upgraded in memory, so
+// that commands reading the configuration keep working.
+func migrateConfig(configFile string, data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if doc == nil {
+		return data, nil
+	}
+
+	version, err := upgradeConfigDoc(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version == configVersion {
+		return data, nil
+	}
+
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config to YAML: %v", err)
+	}
+
+	backup, err := writeMigratedConfig(configFile, version, data, migrated)
+	if err != nil {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: %s uses version %d of the config format and could not be upgraded (%v), using it as is\n",
+			warningStyle.Render("Warning"), configFile, version, err)
+		return migrated, nil
+	}
+
+	noteStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+	fmt.Fprintf(os.Stderr, "%s: upgraded %s from version %d to %d, the original was saved to %s\n",
+		noteStyle.Render("Note"), configFile, version, configVersion, backup)
+
+	return migrated, nil
+}
+
+// writeMigratedConfig backs up the original content of configFile, written
+// with the given version, and replaces it with the migrated content. It
+// returns the name of the backup.
+func writeMigratedConfig(configFile string, version int, original, migrated []byte) (string, error) {
+	backup := fmt.Sprintf("%s.v%d-%s.bak", configFile, version, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, original, 0600); err != nil {
+		return "", fmt.Errorf("failed to back up config file: %v", err)
+	}
+	if err := writeFileAtomic(configFile, migrated); err != nil {
+		os.Remove(backup)
+		return "", err
+	}
+	return backup, nil
+}
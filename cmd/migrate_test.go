@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMigrateConfigUnwritable(t *testing.T) {
+	// A config file in a missing directory cannot be backed up or rewritten,
+	// even by root, like a file on a read-only mount
+	configFile := filepath.Join(t.TempDir(), "missing", "config.yaml")
+	legacy := []byte("api_key: gbx-key\naccount_id: acc-1\n")
+
+	migrated, err := migrateConfig(configFile, legacy)
+	if err != nil {
+		t.Fatalf("migrateConfig() error = %v", err)
+	}
+	if !strings.Contains(string(migrated), "profiles:") || !strings.Contains(string(migrated), "api_key: gbx-key") {
+		t.Errorf("migrateConfig() = %s, want the upgraded content", migrated)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(filepath.Dir(configFile))); len(entries) > 0 {
+		t.Errorf("files written next to the config file: %v", entries)
+	}
+}
+
+func TestUpgradeConfigDoc(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         map[string]interface{}
+		want        map[string]interface{}
+		wantVersion int
+		wantErr     bool
+	}{
+		{
+			name: "version 1",
+			doc:  map[string]interface{}{"api_key": "gbx-key", "account_id": "acc-1"},
+			want: map[string]interface{}{
+				"version":         configVersion,
+				"current_profile": defaultProfile,
+				"profiles": map[string]interface{}{
+					defaultProfile: map[string]interface{}{"api_key": "gbx-key", "account_id": "acc-1"},
+				},
+			},
+			wantVersion: 1,
+		},
+		{
+			name:        "empty version 1",
+			doc:         map[string]interface{}{},
+			want:        map[string]interface{}{"version": configVersion},
+			wantVersion: 1,
+		},
+		{
+			name: "version 1 with profiles",
+			doc:  map[string]interface{}{"profiles": map[string]interface{}{}},
+			want: map[string]interface{}{
+				"version":  configVersion,
+				"profiles": map[string]interface{}{},
+			},
+			wantVersion: 1,
+		},
+		{
+			name: "current version",
+			doc:  map[string]interface{}{"version": 2, "current_profile": "staging"},
+			want: map[string]interface{}{
+				"version":         configVersion,
+				"current_profile": "staging",
+			},
+			wantVersion: 2,
+		},
+		{
+			name:    "newer version",
+			doc:     map[string]interface{}{"version": configVersion + 1},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			doc:     map[string]interface{}{"version": "two"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		version, err := upgradeConfigDoc(tt.doc)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: upgradeConfigDoc() error = nil, want an error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: upgradeConfigDoc() error = %v", tt.name, err)
+			continue
+		}
+		if version != tt.wantVersion || !reflect.DeepEqual(tt.doc, tt.want) {
+			t.Errorf("%s: upgradeConfigDoc() = %d, %v, want %d, %v", tt.name, version, tt.doc, tt.wantVersion, tt.want)
+		}
+	}
+}
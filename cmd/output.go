@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// printStructured prints v as JSON or YAML, for commands with an --output flag
+func printStructured(output string, v interface{}) error {
+	var data []byte
+	var err error
+	switch output {
+	case "json":
+		data, err = json.MarshalIndent(v, "", "  ")
+	case "yaml":
+		data, err = yaml.Marshal(v)
+	default:
+		return fmt.Errorf("unsupported output format %q", output)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s output: %v", strings.ToUpper(output), err)
+	}
+
+	fmt.Println(strings.TrimSuffix(string(data), "\n"))
+	return nil
+}
+
+// validateOutput checks the value of an --output flag against the supported formats
+func validateOutput(output string, formats ...string) error {
+	for _, format := range formats {
+		if output == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q: use %s", output, joinChoices(formats))
+}
+
+// joinChoices lists alternatives as "a, b or c"
+func joinChoices(choices []string) string {
+	if len(choices) < 2 {
+		return strings.Join(choices, "")
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
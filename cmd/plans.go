@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// Define the plans command
+var plansCmd = &cobra.Command{
+	Use:   "plans",
+	Short: "List and compare the subscription plans",
+	Long: `List, describe and compare the subscription plans. The catalog of plans is refreshed from the
+API once a day and cached, so the commands also work offline.`,
+}
+
+// Define the list subcommand
+var plansListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the subscription plans",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPlansList(cmd, args)
+	},
+}
+
+// Define the show subcommand
+var plansShowCmd = &cobra.Command{
+	Use:   "show PLAN",
+	Short: "Describe a subscription plan and its regions",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPlansShow(cmd, args)
+	},
+}
+
+// Define the compare subcommand
+var plansCompareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "Show which regions each plan covers",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPlansCompare(cmd, args)
+	},
+}
+
+func init() {
+	plansCmd.AddCommand(plansListCmd)
+	plansCmd.AddCommand(plansShowCmd)
+	plansCmd.AddCommand(plansCompareCmd)
+
+	plansCmd.PersistentFlags().Bool("refresh", false, "Refresh the catalog from the API instead of using the cache")
+
+	plansListCmd.Flags().StringP("output", "o", "text", "Output format (text, json or yaml)")
+	plansShowCmd.Flags().StringP("output", "o", "text", "Output format (text, json or yaml)")
+	plansCompareCmd.Flags().StringP("continent", "c", "", "Only compare the regions of a continent (e.g., europe)")
+}
+
+// runPlansList handles the 'plans list' command
+func runPlansList(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	if err := validateOutput(output, "text", "json", "yaml"); err != nil {
+		return err
+	}
+
+	catalog, err := loadCatalog(cmd, refresh)
+	if err != nil {
+		return err
+	}
+
+	if output != "text" {
+		return printStructured(output, catalog.Plans)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tREGIONS\tTRIAL\tDESCRIPTION")
+	for _, plan := range catalog.Plans {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", plan.Name, planCoverage(catalog, plan), planTrial(plan), plan.Description)
+	}
+	return w.Flush()
+}
+
+// runPlansShow handles the 'plans show' command
+func runPlansShow(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	if err := validateOutput(output, "text", "json", "yaml"); err != nil {
+		return err
+	}
+
+	catalog, err := loadCatalog(cmd, refresh)
+	if err != nil {
+		return err
+	}
+
+	if err := validatePlanName(args[0]); err != nil {
+		return err
+	}
+	plan, _ := catalog.LookupPlan(args[0])
+
+	if output != "text" {
+		return printStructured(output, plan)
+	}
+
+	fmt.Println(catalog.PlanDetails(plan))
+	return nil
+}
+
+// runPlansCompare handles the 'plans compare' command
+func runPlansCompare(cmd *cobra.Command, args []string) error {
+	continent, _ := cmd.Flags().GetString("continent")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	catalog, err := loadCatalog(cmd, refresh)
+	if err != nil {
+		return err
+	}
+
+	regions := catalog.Regions
+	if continent != "" {
+		if regions, err = filterContinent(catalog, regions, continent); err != nil {
+			return err
+		}
+	}
+
+	// Index the regions each plan covers
+	covered := make([]map[string]bool, len(catalog.Plans))
+	for i, plan := range catalog.Plans {
+		covered[i] = map[string]bool{}
+		for _, region := range catalog.PlanRegions(plan) {
+			covered[i][region.Code] = true
+		}
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "REGION\tCONTINENT\t%s\n", strings.Join(catalog.PlanNames(), "\t"))
+	for _, region := range regions {
+		cells := make([]string, len(catalog.Plans))
+		for i, plan := range catalog.Plans {
+			switch {
+			case !covered[i][region.Code]:
+				cells[i] = "-"
+			case plan.SingleRegion:
+				cells[i] = "choice"
+			default:
+				cells[i] = "yes"
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", region.Code, region.Continent, strings.Join(cells, "\t"))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nyes: probed by the plan, choice: one region of your choice is probed")
+	return nil
+}
+
+// planCoverage summarizes the regions a plan covers
+func planCoverage(catalog *models.Catalog, plan models.Plan) string {
+	switch {
+	case plan.SingleRegion:
+		return fmt.Sprintf("1 of %d", len(catalog.PlanRegions(plan)))
+	case len(plan.RegionCodes) == 0:
+		return fmt.Sprintf("all %d", len(catalog.Regions))
+	}
+	return fmt.Sprintf("%d", len(catalog.PlanRegions(plan)))
+}
+
+// planTrial describes the free trial of a plan
+func planTrial(plan models.Plan) string {
+	if plan.TrialDays == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d days", plan.TrialDays)
+}
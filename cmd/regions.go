@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// Define the regions command
+var regionsCmd = &cobra.Command{
+	Use:   "regions",
+	Short: "List the probe regions",
+	Long: `List the regions Global Blackbox probes from. The catalog of regions is refreshed from the
+API once a day and cached, so the commands also work offline.`,
+}
+
+// Define the list subcommand
+var regionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the probe regions, optionally of one continent or plan",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRegionsList(cmd, args)
+	},
+}
+
+func init() {
+	regionsCmd.AddCommand(regionsListCmd)
+
+	regionsCmd.PersistentFlags().Bool("refresh", false, "Refresh the catalog from the API instead of using the cache")
+
+	regionsListCmd.Flags().StringP("continent", "c", "", "Only list the regions of a continent (e.g., europe, middle-east)")
+	regionsListCmd.Flags().StringP("plan", "p", "", "Only list the regions of a plan (e.g., all-continents)")
+	regionsListCmd.Flags().StringP("output", "o", "text", "Output format (text, json or yaml)")
+}
+
+// runRegionsList handles the 'regions list' command
+func runRegionsList(cmd *cobra.Command, args []string) error {
+	continent, _ := cmd.Flags().GetString("continent")
+	planName, _ := cmd.Flags().GetString("plan")
+	output, _ := cmd.Flags().GetString("output")
+	refresh, _ := cmd.Flags().GetBool("refresh")
+
+	if err := validateOutput(output, "text", "json", "yaml"); err != nil {
+		return err
+	}
+
+	catalog, err := loadCatalog(cmd, refresh)
+	if err != nil {
+		return err
+	}
+
+	regions := catalog.Regions
+	if planName != "" {
+		if err := validatePlanName(planName); err != nil {
+			return err
+		}
+		plan, _ := catalog.LookupPlan(planName)
+		regions = catalog.PlanRegions(plan)
+	}
+	if continent != "" {
+		if regions, err = filterContinent(catalog, regions, continent); err != nil {
+			return err
+		}
+	}
+
+	if output != "text" {
+		return printStructured(output, regions)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CODE\tCITY\tCOUNTRY\tCONTINENT")
+	for _, region := range regions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", region.Code, region.City, region.Country, region.Continent)
+	}
+	return w.Flush()
+}
+
+// filterContinent keeps the regions of a continent, given by name or by the
+// suffix of its region codes, e.g. "Middle East" or "middle-east"
+func filterContinent(catalog *models.Catalog, regions []models.Region, continent string) ([]models.Region, error) {
+	matches := func(region models.Region) bool {
+		return strings.EqualFold(region.Continent, continent) ||
+			strings.HasSuffix(region.Code, "."+strings.ToLower(continent))
+	}
+
+	var known bool
+	for _, region := range catalog.Regions {
+		known = known || matches(region)
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown continent %q, expected one of: %s", continent, strings.Join(continents(catalog), ", "))
+	}
+
+	var filtered []models.Region
+	for _, region := range regions {
+		if matches(region) {
+			filtered = append(filtered, region)
+		}
+	}
+	return filtered, nil
+}
+
+// continents lists the continents of the catalog's regions, in catalog order
+func continents(catalog *models.Catalog) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, region := range catalog.Regions {
+		if !seen[region.Continent] {
+			seen[region.Continent] = true
+			names = append(names, region.Continent)
+		}
+	}
+	return names
+}
@@ -1,11 +1,18 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+
+	"globalblackbox.io/gbx/client"
 )
 
 // rootCmd represents the base command
@@ -13,16 +20,117 @@ var rootCmd = &cobra.Command{
 	Use:   "gbx",
 	Short: "gbx is the CLI tool to interact with the Global Blackbox API",
 	Long:  `GBX allows you to sign up and interact with Global Blackbox API through a command-line interface.`,
+
+	// Errors are printed by Execute, which also picks the exit code
+	SilenceErrors: true,
+	SilenceUsage:  true,
+
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyTimeout(cmd)
+	},
+}
+
+// networkTime limits the time spent in network operations to --timeout
+var networkTime = &networkTimer{}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&apiKeyFile, "api-key-file", "", "Read the API key from this file, e.g. a mounted secret (overrides GBX_API_KEY)")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Configuration profile to use (overrides GBX_PROFILE and the current profile)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "Abort once network operations take longer than this in total, time at prompts excluded, e.g. 30s or 5m (0 means no limit)")
+	rootCmd.PersistentFlags().Bool("debug", false, "Log API requests and responses to stderr, with API keys redacted (or set GBX_DEBUG=1)")
+	rootCmd.PersistentFlags().String("api-url", client.DefaultBaseURL, "Global Blackbox API endpoint (overrides GBX_API_URL and api_url in the config file)")
+	rootCmd.PersistentFlags().Int("max-retries", client.DefaultMaxRetries, "Maximum number of retries for failed idempotent API requests")
+	rootCmd.PersistentFlags().Duration("retry-timeout", client.DefaultRetryTimeout, "Maximum total time spent retrying an API request")
+	rootCmd.PersistentFlags().String("proxy", "", "Proxy URL for API requests (defaults to HTTPS_PROXY/NO_PROXY from the environment)")
+	rootCmd.PersistentFlags().StringSlice("ca-bundle", nil, "PEM file with extra CA certificates to trust (repeatable)")
+	rootCmd.PersistentFlags().String("client-cert", "", "PEM client certificate for mutual TLS")
+	rootCmd.PersistentFlags().String("client-key", "", "PEM private key for the mutual TLS client certificate")
+	rootCmd.PersistentFlags().String("tls-min-version", "1.2", "Minimum TLS version (1.2 or 1.3)")
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
 	rootCmd.AddCommand(signupCmd)
 	rootCmd.AddCommand(logsCmd)
+	rootCmd.AddCommand(regionsCmd)
+	rootCmd.AddCommand(plansCmd)
+	rootCmd.AddCommand(authCmd)
+	rootCmd.AddCommand(accountCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(devCmd)
 
-	if err := rootCmd.Execute(); err != nil {
+	// The first interrupt cancels the running operation; restoring the default
+	// signal handling afterwards lets a second one kill the process outright
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	context.AfterFunc(ctx, stop)
+
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
+		code := exitCodeFor(err)
+		switch code {
+		case exitInterrupted:
+			err = fmt.Errorf("operation interrupted")
+		case exitTimeout:
+			err = fmt.Errorf("operation timed out, see --timeout: %v", err)
+		}
 		style := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("1"))
 		fmt.Fprintf(os.Stderr, "%s: %v\n", style.Render("Error"), err)
-		os.Exit(1)
+		os.Exit(code)
+	}
+}
+
+// applyTimeout sets the time limit of network operations from the --timeout flag
+func applyTimeout(cmd *cobra.Command) error {
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	if timeout < 0 {
+		return fmt.Errorf("--timeout cannot be negative")
+	}
+	networkTime = &networkTimer{limit: timeout}
+	return nil
+}
+
+// networkTimer accounts for the time spent in network operations, so that
+// --timeout does not count the time spent answering prompts. Operations may
+// nest, e.g. the requests made while waiting for a payment.
+type networkTimer struct {
+	// limit is the total time allowed, zero meaning no limit
+	limit time.Duration
+
+	mu     sync.Mutex
+	spent  time.Duration
+	active int
+	start  time.Time
+}
+
+// begin starts a network operation. The returned context expires when the
+// time limit is reached; end must be called once the operation is over.
+func (t *networkTimer) begin(ctx context.Context) (context.Context, func()) {
+	if t.limit == 0 {
+		return ctx, func() {}
+	}
+
+	t.mu.Lock()
+	if t.active == 0 {
+		t.start = time.Now()
+	}
+	t.active++
+	deadline := t.start.Add(t.limit - t.spent)
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			cancel()
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.active--; t.active == 0 {
+				t.spent += time.Since(t.start)
+			}
+		})
 	}
 }
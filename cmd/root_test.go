@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNetworkTimer(t *testing.T) {
+	timer := &networkTimer{limit: 200 * time.Millisecond}
+
+	// An operation within the limit, then time outside of any operation,
+	// e.g. at a prompt, which does not count
+	ctx, end := timer.begin(context.Background())
+	time.Sleep(50 * time.Millisecond)
+	end()
+	if ctx.Err() == nil {
+		t.Errorf("context not released when the operation ended")
+	}
+	time.Sleep(250 * time.Millisecond)
+
+	// Nested operations share what is left of the limit
+	outer, endOuter := timer.begin(context.Background())
+	defer endOuter()
+	inner, endInner := timer.begin(outer)
+	defer endInner()
+	if err := inner.Err(); err != nil {
+		t.Fatalf("time outside of operations counted: %v", err)
+	}
+
+	select {
+	case <-inner.Done():
+	case <-time.After(time.Second):
+		t.Fatal("time limit not enforced")
+	}
+	if !errors.Is(inner.Err(), context.DeadlineExceeded) || !errors.Is(outer.Err(), context.DeadlineExceeded) {
+		t.Errorf("errors = %v, %v, want context.DeadlineExceeded", inner.Err(), outer.Err())
+	}
+}
+
+func TestNetworkTimerNoLimit(t *testing.T) {
+	ctx, end := (&networkTimer{}).begin(context.Background())
+	defer end()
+	if _, ok := ctx.Deadline(); ok {
+		t.Errorf("deadline set without a limit")
+	}
+}
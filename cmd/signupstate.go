@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// signupState is a sign-up in progress. It is saved before the request is
+// sent and kept until the account is paid for, so that a failed sign-up can
+// be resumed without creating a second account, and the payment link can be
+// shown again. The last sign-up is kept in signup.json; an unpaid one is moved
+// to a file of its account when another sign-up starts.
+type signupState struct {
+	IdempotencyKey string               `json:"idempotency_key"`
+	Request        models.SignupRequest `json:"request"`
+	StartedAt      time.Time            `json:"started_at"`
+
+	// Set once the API has created the account
+	AccountID string `json:"account_id,omitempty"`
+	StripeURL string `json:"stripe_url,omitempty"`
+}
+
+// signupStatePath returns the path of the state of the last sign-up, next to
+// the configuration file
+func signupStatePath() (string, error) {
+	configDir, _, err := configPaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "signup.json"), nil
+}
+
+// accountSignupStatePath returns the path where the state of an earlier
+// sign-up is kept until its account is paid for
+func accountSignupStatePath(accountID string) (string, error) {
+	configDir, _, err := configPaths()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "signup-"+url.PathEscape(accountID)+".json"), nil
+}
+
+// loadSignupState returns the last sign-up, or nil when there is none
+func loadSignupState() (*signupState, error) {
+	path, err := signupStatePath()
+	if err != nil {
+		return nil, err
+	}
+	return readSignupState(path)
+}
+
+// loadAccountSignupState returns the sign-up of an account, or nil when it
+// is not followed
+func loadAccountSignupState(accountID string) (*signupState, error) {
+	state, err := loadSignupState()
+	if err != nil || (state != nil && state.AccountID == accountID) {
+		return state, err
+	}
+
+	path, err := accountSignupStatePath(accountID)
+	if err != nil {
+		return nil, err
+	}
+	return readSignupState(path)
+}
+
+// readSignupState reads a sign-up state file, returning nil when it does not exist
+func readSignupState(path string) (*signupState, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sign-up state: %v", err)
+	}
+
+	var state signupState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse sign-up state %s: %v", path, err)
+	}
+	return &state, nil
+}
+
+// saveSignupState saves the last sign-up
+func saveSignupState(state *signupState) error {
+	path, err := signupStatePath()
+	if err != nil {
+		return err
+	}
+	return writeSignupState(path, state)
+}
+
+// keepSignupState moves the state of a sign-up waiting for its payment out
+// of the way of a new sign-up, so that its payment link is not lost
+func keepSignupState(state *signupState) error {
+	path, err := accountSignupStatePath(state.AccountID)
+	if err != nil {
+		return err
+	}
+	return writeSignupState(path, state)
+}
+
+// writeSignupState writes a sign-up state file
+func writeSignupState(path string, state *signupState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal sign-up state: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	return writeFileAtomic(path, data)
+}
+
+// removeSignupState forgets the last sign-up once it is complete or rejected
+func removeSignupState() error {
+	path, err := signupStatePath()
+	if err != nil {
+		return err
+	}
+	return removeSignupStateFile(path)
+}
+
+// removeAccountSignupState forgets the sign-up of an account once it is paid for
+func removeAccountSignupState(accountID string) error {
+	if state, err := loadSignupState(); err == nil && state != nil && state.AccountID == accountID {
+		if err := removeSignupState(); err != nil {
+			return err
+		}
+	}
+
+	path, err := accountSignupStatePath(accountID)
+	if err != nil {
+		return err
+	}
+	return removeSignupStateFile(path)
+}
+
+// removeSignupStateFile removes a sign-up state file if it exists
+func removeSignupStateFile(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove sign-up state: %v", err)
+	}
+	return nil
+}
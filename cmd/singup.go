@@ -2,31 +2,286 @@
 package cmd
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/manifoldco/promptui"
 	"github.com/spf13/cobra"
+	"globalblackbox.io/gbx/client"
 	"globalblackbox.io/gbx/models"
+	"gopkg.in/yaml.v2"
 )
 
 // Define the signup command
 var signupCmd = &cobra.Command{
 	Use:   "sign-up",
 	Short: "Sign up for a Global Blackbox account",
-	Long:  `Interactively sign up for a Global Blackbox account by providing your email and selecting a subscription plan.`,
-	Run: func(cmd *cobra.Command, args []string) {
-		runSignup()
+	Long: `Interactively sign up for a Global Blackbox account by providing your email and selecting a subscription plan.
+
+The answers can also be given with flags or read from a YAML file, e.g. to provision accounts from scripts:
+
+  gbx sign-up --email ops@example.com --plan single-region --region london.europe --targets 10 --yes
+
+  # signup.yaml
+  email: ops@example.com
+  plan:
+    name: single-region
+    region: london.europe
+    number_of_targets: 10
+
+Flags override the values of the file. Missing values are prompted for, unless --yes is given or the
+output is json or yaml, in which case sign-up fails instead.
+
+The new account is saved to the active profile. If that profile already holds credentials, you are asked
+whether to save the account under a new profile name or to back up the config file and replace them. Without
+prompts the account gets a profile of its own, a profile selected with --profile is never overwritten unless
+--force is given, and --config-out writes the account to a separate config file instead.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSignup(cmd)
 	},
 }
 
+func init() {
+	signupCmd.Flags().String("email", "", "Email address of the account")
+	signupCmd.Flags().String("plan", "", fmt.Sprintf("Subscription plan (%s)", strings.Join(models.PlanNames, ", ")))
+	signupCmd.Flags().String("region", "", "Region code of the single-region plan, e.g. london.europe")
+	signupCmd.Flags().Int("targets", 0, "Number of probe targets to monitor")
+	signupCmd.Flags().String("from-file", "", "Read the sign-up request from a YAML file")
+	signupCmd.Flags().BoolP("yes", "y", false, "Sign up without asking for confirmation or missing values")
+	signupCmd.Flags().Bool("resume", false, "Retry the last sign-up that did not complete")
+	for _, flag := range []string{"email", "plan", "region", "targets", "from-file"} {
+		signupCmd.MarkFlagsMutuallyExclusive("resume", flag)
+	}
+	signupCmd.Flags().String("config-out", "", "Write the new account to this config file instead of the default one")
+	signupCmd.Flags().Bool("force", false, "Replace existing credentials, keeping a backup of the file they are in")
+	signupCmd.Flags().StringP("output", "o", "text", "Output format (text, json or yaml)")
+}
+
 // runSignup orchestrates the sign-up process
-func runSignup() {
+func runSignup(cmd *cobra.Command) error {
+	output, _ := cmd.Flags().GetString("output")
+	if err := validateOutput(output, "text", "json", "yaml"); err != nil {
+		return err
+	}
+	yes, _ := cmd.Flags().GetBool("yes")
+	interactive := !yes && output == "text"
+
+	file, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	state, err := loadSignupState()
+	if err != nil {
+		return err
+	}
+
+	var signupReq models.SignupRequest
+	resume, _ := cmd.Flags().GetBool("resume")
+	if resume {
+		if state == nil {
+			return fmt.Errorf("there is no sign-up to resume")
+		}
+		signupReq = state.Request
+		if output == "text" {
+			fmt.Printf("Resuming the sign-up of %s started on %s.\n", signupReq.Email, state.StartedAt.Local().Format(time.DateTime))
+		}
+	} else {
+		if signupReq, err = signupRequestFromFlags(cmd); err != nil {
+			return err
+		}
+		switch {
+		case state != nil && state.AccountID != "":
+			noteSignupProfile(state)
+		case state != nil && output == "text":
+			fmt.Printf("Note: the sign-up of %s started on %s did not complete, run 'gbx sign-up --resume' to finish it.\n\n",
+				state.Request.Email, state.StartedAt.Local().Format(time.DateTime))
+		}
+	}
+	if err := validateSignupRequest(signupReq); err != nil {
+		return err
+	}
+
+	missing := missingSignupFlags(signupReq)
+	switch {
+	case len(missing) > 0 && !interactive:
+		return fmt.Errorf("missing %s for a non-interactive sign-up", strings.Join(missing, ", "))
+	case len(missing) > 0 || interactive:
+		if !stdinIsTerminal() {
+			return fmt.Errorf("cannot prompt for the sign-up details without a terminal, pass them as flags with --yes")
+		}
+	}
+
+	// Check where the account will be saved before asking for its details
+	target, err := chooseSignupTarget(cmd, file, interactive)
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		if err := promptSignupRequest(&signupReq); err != nil {
+			return err
+		}
+	} else if interactive {
+		confirmed, err := confirmSignup(signupReq)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return fmt.Errorf("sign-up cancelled")
+		}
+	}
+
+	apiClient, err := newAPIClient(cmd, "")
+	if err != nil {
+		return err
+	}
+
+	// Retrying a sign-up that got no answer reuses its idempotency key, so
+	// the API answers with the account it may already have created. Once the
+	// account is known, the same request signs up another account.
+	if !resume && (state == nil || state.AccountID != "" || state.Request != signupReq) {
+		if state != nil && state.AccountID != "" {
+			if err := keepSignupState(state); err != nil {
+				return err
+			}
+		}
+		state = &signupState{
+			IdempotencyKey: client.NewIdempotencyKey(),
+			Request:        signupReq,
+			StartedAt:      time.Now().UTC(),
+		}
+		if err := saveSignupState(state); err != nil {
+			return err
+		}
+	}
+
+	response, err := sendSignupRequest(cmd.Context(), apiClient, signupReq, state.IdempotencyKey, output)
+	var apiErr *client.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode < 500 && !errors.Is(err, client.ErrRateLimited):
+		// The API rejected the request, there is nothing to resume
+		if err := removeSignupState(); err != nil {
+			return err
+		}
+		return err
+	case err != nil:
+		return fmt.Errorf("%w, run 'gbx sign-up --resume' to retry without creating a second account", err)
+	}
+
+	state.AccountID, state.StripeURL = response.AccountID, response.StripeURL
+	if err := saveSignupState(state); err != nil {
+		return err
+	}
+
+	if output != "text" {
+		return printSignupResponse(response, file, target, output)
+	}
+
+	displayResponse(response, file, target)
+	return nil
+}
+
+// signupRequestFromFlags builds the sign-up request from --from-file and the
+// flags, which take precedence over the file. Values not given are left empty.
+func signupRequestFromFlags(cmd *cobra.Command) (models.SignupRequest, error) {
+	var req models.SignupRequest
+	flags := cmd.Flags()
+
+	if path, _ := flags.GetString("from-file"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return req, fmt.Errorf("failed to read sign-up file: %v", err)
+		}
+		if err := yaml.UnmarshalStrict(data, &req); err != nil {
+			return req, fmt.Errorf("failed to parse sign-up file %s: %v", path, err)
+		}
+	}
+
+	if flags.Changed("email") {
+		req.Email, _ = flags.GetString("email")
+	}
+	if flags.Changed("plan") {
+		req.Plan.Name, _ = flags.GetString("plan")
+	}
+	if flags.Changed("region") {
+		req.Plan.Region, _ = flags.GetString("region")
+	}
+	if flags.Changed("targets") {
+		req.Plan.NumberOfTargets, _ = flags.GetInt("targets")
+		if req.Plan.NumberOfTargets <= 0 {
+			return req, fmt.Errorf("--targets must be a positive integer")
+		}
+	}
+
+	req.Email = strings.TrimSpace(req.Email)
+	req.Plan.Name = strings.TrimSpace(req.Plan.Name)
+	req.Plan.Region = strings.TrimSpace(req.Plan.Region)
+	return req, nil
+}
+
+// validateSignupRequest checks the values given for a sign-up with the same
+// rules as the prompts. Missing values are not an error.
+func validateSignupRequest(req models.SignupRequest) error {
+	if req.Email != "" {
+		if err := validateEmail(req.Email); err != nil {
+			return err
+		}
+	}
+	if req.Plan.Name != "" {
+		if err := validatePlanName(req.Plan.Name); err != nil {
+			return err
+		}
+	}
+	if req.Plan.Region != "" {
+		if req.Plan.Name != "" && !planChoosesRegion(req.Plan.Name) {
+			return fmt.Errorf("a region can only be chosen with the single-region plan")
+		}
+		if err := validateRegion(req.Plan.Region); err != nil {
+			return err
+		}
+	}
+	if req.Plan.NumberOfTargets < 0 {
+		return fmt.Errorf("please enter a valid positive integer for the number of targets")
+	}
+	return nil
+}
+
+// missingSignupFlags lists the flags of the values a sign-up request still needs
+func missingSignupFlags(req models.SignupRequest) []string {
+	var missing []string
+	if req.Email == "" {
+		missing = append(missing, "--email")
+	}
+	if req.Plan.Name == "" {
+		missing = append(missing, "--plan")
+	}
+	if (req.Plan.Name == "" || planChoosesRegion(req.Plan.Name)) && req.Plan.Region == "" {
+		missing = append(missing, "--region")
+	}
+	if req.Plan.NumberOfTargets == 0 {
+		missing = append(missing, "--targets")
+	}
+	return missing
+}
+
+// stdinIsTerminal reports whether the prompts can read answers from a terminal
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// promptSignupRequest prompts for the values missing from req
+func promptSignupRequest(req *models.SignupRequest) error {
 	welcomeStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#D3D3D3")) // Light Grey
@@ -39,25 +294,26 @@ func runSignup() {
 		Foreground(lipgloss.Color("#A9A9A9")) // Medium Grey
 	fmt.Println(docStyle.Render("For more information on subscription plans, visit: https://globalblackbox.io/docs/plans/\n"))
 
-	email, err := promptEmail()
-	if err != nil {
-		exitWithError(err)
+	var err error
+	if req.Email == "" {
+		if req.Email, err = promptEmail(); err != nil {
+			return err
+		}
 	}
 
-	var planName string
-	for {
-		planName, err = promptPlan()
+	for req.Plan.Name == "" {
+		planName, err := promptPlan()
 		if err != nil {
-			exitWithError(err)
+			return err
 		}
 
 		confirmed, err := confirmPlan(planName)
 		if err != nil {
-			exitWithError(err)
+			return err
 		}
 
 		if confirmed {
-			break
+			req.Plan.Name = planName
 		} else {
 			fmt.Println("\n" + lipgloss.NewStyle().
 				Bold(true).
@@ -66,34 +322,297 @@ func runSignup() {
 		}
 	}
 
-	var region string
-	if planName == "single-region" {
-		region, err = promptRegion()
-		if err != nil {
-			exitWithError(err)
+	if planChoosesRegion(req.Plan.Name) && req.Plan.Region == "" {
+		if req.Plan.Region, err = promptRegion(req.Plan.Name); err != nil {
+			return err
+		}
+	}
+	if !planChoosesRegion(req.Plan.Name) {
+		req.Plan.Region = ""
+	}
+
+	if req.Plan.NumberOfTargets == 0 {
+		if req.Plan.NumberOfTargets, err = promptNumberOfTargets(0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// confirmSignup asks to confirm a sign-up whose details were all given as flags
+func confirmSignup(req models.SignupRequest) (bool, error) {
+	plan := req.Plan.Name
+	if req.Plan.Region != "" {
+		plan += " in " + req.Plan.Region
+	}
+
+	prompt := promptui.Prompt{
+		Label:     fmt.Sprintf("Sign up %s for the %s plan with %d targets", req.Email, plan, req.Plan.NumberOfTargets),
+		IsConfirm: true,
+	}
+
+	_, err := prompt.Run()
+	if errors.Is(err, promptui.ErrAbort) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// signupTarget is where a new account is saved
+type signupTarget struct {
+	// profile is the profile name, empty to name a new profile after the account ID
+	profile string
+
+	// backup copies the file the account is saved to before replacing credentials
+	backup bool
+
+	// configOut is a separate config file written for the account, see --config-out
+	configOut string
+}
+
+// chooseSignupTarget decides where a new account is saved, so that existing
+// credentials are never overwritten by accident. An active profile holding
+// credentials is only replaced with --force or when the user picks it at the
+// prompt, and the config file is backed up first. Without prompts the account
+// gets a profile of its own, unless the profile was selected with --profile
+// or GBX_PROFILE, which is an error.
+func chooseSignupTarget(cmd *cobra.Command, file *models.ConfigFile, interactive bool) (*signupTarget, error) {
+	force, _ := cmd.Flags().GetBool("force")
+
+	if configOut, _ := cmd.Flags().GetString("config-out"); configOut != "" {
+		target := &signupTarget{profile: defaultProfile, configOut: configOut}
+		if profileFlag != "" {
+			target.profile = profileFlag
 		}
+		if !fileExists(configOut) {
+			return target, nil
+		}
+
+		switch {
+		case force:
+		case !interactive:
+			return nil, fmt.Errorf("%s already exists, pass --force to replace it", configOut)
+		default:
+			prompt := promptui.Prompt{
+				Label:     fmt.Sprintf("%s already exists, back it up and replace it", configOut),
+				IsConfirm: true,
+			}
+			if _, err := prompt.Run(); errors.Is(err, promptui.ErrAbort) {
+				return nil, fmt.Errorf("sign-up cancelled")
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		target.backup = true
+		return target, nil
 	}
 
-	numberOfTargets, err := promptNumberOfTargets()
+	name := activeProfile(file)
+	existing, exists := file.Profiles[name]
+	if !exists || (existing.APIKey == "" && existing.AccountID == "") {
+		return &signupTarget{profile: name}, nil
+	}
+
+	switch {
+	case force:
+		return &signupTarget{profile: name, backup: true}, nil
+	case interactive:
+		return promptSignupTarget(file, name, existing)
+	case profileSelected():
+		return nil, fmt.Errorf("profile %q already holds the credentials of account %s, choose another --profile or pass --force to replace them",
+			name, valueOrNone(existing.AccountID))
+	}
+	return &signupTarget{}, nil
+}
+
+// promptSignupTarget asks where to save a new account when the active profile
+// already holds credentials
+func promptSignupTarget(file *models.ConfigFile, name string, existing *models.Config) (*signupTarget, error) {
+	warningStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#696969"))
+	fmt.Printf("%s: profile %q already holds the credentials of account %s.\n\n",
+		warningStyle.Render("Warning"), name, valueOrNone(existing.AccountID))
+
+	prompt := promptui.Select{
+		Label: "Where do you want to save the new account?",
+		Items: []string{
+			"Under a new profile name",
+			fmt.Sprintf("In profile %q, after backing up the config file", name),
+			"Abort",
+		},
+	}
+
+	index, _, err := prompt.Run()
 	if err != nil {
-		exitWithError(err)
+		return nil, fmt.Errorf("selection prompt failed: %w", err)
+	}
+
+	switch index {
+	case 0:
+		profile, err := promptProfileName(file)
+		if err != nil {
+			return nil, err
+		}
+		return &signupTarget{profile: profile}, nil
+	case 1:
+		return &signupTarget{profile: name, backup: true}, nil
 	}
+	return nil, fmt.Errorf("sign-up cancelled")
+}
 
-	signupReq := models.SignupRequest{
-		Email: email,
-		Plan: models.SignupPlan{
-			Name:            planName,
-			Region:          region,
-			NumberOfTargets: numberOfTargets,
+// promptProfileName prompts for the name of a new profile. An empty name
+// names the profile after the account ID.
+func promptProfileName(file *models.ConfigFile) (string, error) {
+	prompt := promptui.Prompt{
+		Label: "Enter a name for the new profile (leave empty to use the account ID)",
+		Validate: func(input string) error {
+			input = strings.TrimSpace(input)
+			if strings.ContainsAny(input, " \t") {
+				return fmt.Errorf("profile name cannot contain spaces")
+			}
+			if _, exists := file.Profiles[input]; exists && input != "" {
+				return fmt.Errorf("profile %q already exists", input)
+			}
+			return nil
 		},
 	}
 
-	response, err := sendSignupRequest(signupReq)
+	name, err := prompt.Run()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(name), nil
+}
+
+// newProfileName derives an unused profile name from the account ID
+func newProfileName(file *models.ConfigFile, accountID string) string {
+	base := accountID
+	if base == "" {
+		base = "account"
+	}
+
+	name := base
+	for i := 2; ; i++ {
+		if _, exists := file.Profiles[name]; !exists {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// saveSignupProfile saves the new account where target says and returns the
+// profile name and the config file it was saved to. Settings such as api_url
+// are carried over from the active profile, since the account was created
+// with them.
+func saveSignupProfile(file *models.ConfigFile, target *signupTarget, resp *models.SignupResponse) (string, string, error) {
+	// A replayed sign-up, e.g. with --resume, updates the profile already
+	// holding the account instead of adding another one
+	source := activeProfile(file)
+	existing := ""
+	if target.configOut == "" {
+		existing = accountProfile(file, resp.AccountID)
+	}
+	if existing != "" {
+		source = existing
+	}
+
+	config := &models.Config{}
+	if active, exists := file.Profiles[source]; exists {
+		copied := *active
+		config = &copied
+	}
+
+	config.APIKey = resp.APIKey
+	config.AccountID = resp.AccountID
+	config.Plan = models.SignupPlan{
+		Name:   resp.Plan.Name,
+		Region: resp.Plan.Region,
+	}
+	config.NumberOfTargets = resp.Plan.NumberOfTargets
+
+	if target.configOut != "" {
+		return target.profile, target.configOut, writeSignupConfig(target, config)
+	}
+
+	_, configFile, err := configPaths()
 	if err != nil {
-		exitWithError(err)
+		return "", "", err
 	}
 
-	displayResponse(response)
+	profile := target.profile
+	switch {
+	case existing != "":
+		return existing, configFile, saveProfile(file, existing, config)
+	case profile == "":
+		profile = newProfileName(file, resp.AccountID)
+	}
+	if target.backup {
+		if err := backupSignupCredentials(configFile, file.Profiles[profile]); err != nil {
+			return "", "", err
+		}
+	}
+	return profile, configFile, saveProfile(file, profile, config)
+}
+
+// writeSignupConfig writes a config file holding only the new account. The
+// API key is kept in the file, since credential stores belong to the default
+// config directory.
+func writeSignupConfig(target *signupTarget, config *models.Config) error {
+	config.CredentialStore = ""
+	config.CredentialHelper = ""
+
+	if target.backup {
+		if err := backupSignupCredentials(target.configOut, nil); err != nil {
+			return err
+		}
+	}
+
+	data, err := yaml.Marshal(&models.ConfigFile{
+		Version:        configVersion,
+		CurrentProfile: target.profile,
+		Profiles:       map[string]*models.Config{target.profile: config},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal config to YAML: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target.configOut), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %v", err)
+	}
+	return writeFileAtomic(target.configOut, data)
+}
+
+// backupSignupCredentials backs up a config file, and the encrypted
+// credentials of the profile about to be replaced, before sign-up overwrites them
+func backupSignupCredentials(configFile string, replaced *models.Config) error {
+	paths := []string{configFile}
+	if replaced != nil && replaced.CredentialStore == "encrypted" {
+		paths = append(paths, filepath.Join(filepath.Dir(configFile), "credentials.enc"))
+	}
+
+	noteStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+	for _, path := range paths {
+		if !fileExists(path) {
+			continue
+		}
+		backup, err := backupFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s: the previous %s was saved to %s\n", noteStyle.Render("Note"), filepath.Base(path), backup)
+	}
+
+	if replaced != nil && replaced.CredentialHelper != "" {
+		fmt.Fprintf(os.Stderr, "%s: the API key kept by %s for account %s is replaced\n",
+			noteStyle.Render("Note"), replaced.CredentialHelper, valueOrNone(replaced.AccountID))
+	}
+	return nil
 }
 
 // displayPricingInfo displays information about how pricing works
@@ -109,22 +628,73 @@ The total cost is determined by the combination of your selected plan and the nu
 	fmt.Println()
 }
 
-// promptEmail prompts the user to enter their email address
-func promptEmail() (string, error) {
-	validate := func(input string) error {
-		input = strings.TrimSpace(input)
-		if input == "" {
-			return fmt.Errorf("email cannot be empty")
-		}
-		if !strings.Contains(input, "@") || !strings.Contains(input, ".") {
-			return fmt.Errorf("invalid email address")
-		}
+// validateEmail checks an email address given for a sign-up
+func validateEmail(input string) error {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return fmt.Errorf("email cannot be empty")
+	}
+	if !strings.Contains(input, "@") || !strings.Contains(input, ".") {
+		return fmt.Errorf("invalid email address %q", input)
+	}
+	return nil
+}
+
+// validatePlanName checks that a subscription plan exists
+func validatePlanName(input string) error {
+	catalog := localCatalog()
+	if _, ok := catalog.LookupPlan(input); !ok {
+		return fmt.Errorf("unknown plan %q, expected one of: %s", input, strings.Join(catalog.PlanNames(), ", "))
+	}
+	return nil
+}
+
+// planChoosesRegion reports whether the region of a plan is chosen at sign-up
+func planChoosesRegion(planName string) bool {
+	plan, ok := localCatalog().LookupPlan(planName)
+	return ok && plan.SingleRegion
+}
+
+// validateRegion checks that a region code exists, suggesting the closest
+// codes for a typo
+func validateRegion(input string) error {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return fmt.Errorf("region cannot be empty")
+	}
+	catalog := localCatalog()
+	if _, ok := catalog.LookupRegion(input); ok {
 		return nil
 	}
 
+	suggestions := catalog.SuggestRegions(input)
+	if len(suggestions) == 0 {
+		return fmt.Errorf("unknown region code %q, run 'gbx regions list' to see the available regions", input)
+	}
+	for i, code := range suggestions {
+		suggestions[i] = strconv.Quote(code)
+	}
+	return fmt.Errorf("unknown region code %q, did you mean %s?", input, strings.Join(suggestions, " or "))
+}
+
+// validateNumberOfTargets checks and parses a number of probe targets
+func validateNumberOfTargets(input string) (int, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return 0, fmt.Errorf("number of targets cannot be empty")
+	}
+	number, err := strconv.Atoi(input)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("please enter a valid positive integer for the number of targets")
+	}
+	return number, nil
+}
+
+// promptEmail prompts the user to enter their email address
+func promptEmail() (string, error) {
 	prompt := promptui.Prompt{
 		Label:    "Enter your email address",
-		Validate: validate,
+		Validate: validateEmail,
 	}
 
 	email, err := prompt.Run()
@@ -132,12 +702,12 @@ func promptEmail() (string, error) {
 		return "", err
 	}
 
-	return email, nil
+	return strings.TrimSpace(email), nil
 }
 
 // promptPlan prompts the user to select a subscription plan
 func promptPlan() (string, error) {
-	plans := []string{"single-region", "all-continents", "worldwide"}
+	plans := localCatalog().PlanNames()
 
 	prompt := promptui.Select{
 		Label: "Select a subscription plan",
@@ -155,10 +725,12 @@ func promptPlan() (string, error) {
 
 // confirmPlan displays the plan details and prompts the user to confirm or re-select
 func confirmPlan(planName string) (bool, error) {
-	description, exists := models.PlanDetails[planName]
+	catalog := localCatalog()
+	plan, exists := catalog.LookupPlan(planName)
 	if !exists {
 		return false, fmt.Errorf("no details found for the selected plan: %s", planName)
 	}
+	description := catalog.PlanDetails(plan)
 
 	planStyle := lipgloss.NewStyle().
 		Bold(true).
@@ -173,7 +745,7 @@ func confirmPlan(planName string) (bool, error) {
 
 	_, result, err := confirmPrompt.Run()
 	if err != nil {
-		return false, fmt.Errorf("confirmation prompt failed: %v", err)
+		return false, fmt.Errorf("confirmation prompt failed: %w", err)
 	}
 
 	if result == "Confirm" {
@@ -183,98 +755,167 @@ func confirmPlan(planName string) (bool, error) {
 	}
 }
 
-// promptRegion prompts the user to enter a region if "single-region" plan is selected
-func promptRegion() (string, error) {
-	validate := func(input string) error {
-		if strings.TrimSpace(input) == "" {
-			return fmt.Errorf("region cannot be empty")
+// promptRegion lets the user pick the region of a single-region plan from a
+// list grouped by continent, filtered as they type a city, country or code,
+// and confirm the choice
+func promptRegion(planName string) (string, error) {
+	catalog := localCatalog()
+	plan, _ := catalog.LookupPlan(planName)
+	regions := regionsByContinent(catalog.PlanRegions(plan))
+
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}",
+		Active:   `▸ {{ printf "%-12s" .Continent | faint }} {{ .City | cyan }}, {{ .Country | cyan }} ({{ .Code }})`,
+		Inactive: `  {{ printf "%-12s" .Continent | faint }} {{ .City }}, {{ .Country }} ({{ .Code | faint }})`,
+		Selected: `{{ "Region:" | faint }} {{ .Code }}`,
+		Details: `
+{{ "Code:" | faint }}	{{ .Code }}
+{{ "City:" | faint }}	{{ .City }}
+{{ "Country:" | faint }}	{{ .Country }}
+{{ "Continent:" | faint }}	{{ .Continent }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		region := regions[index]
+		input = normalizeSearch(input)
+		for _, field := range []string{region.City, region.Country, region.Code, region.Continent} {
+			if strings.Contains(normalizeSearch(field), input) {
+				return true
+			}
 		}
-		return nil
+		return false
 	}
 
-	prompt := promptui.Prompt{
-		Label:    "Enter your desired region (e.g., sao-paulo.americas)",
-		Validate: validate,
+	for {
+		prompt := promptui.Select{
+			Label:             "Select your region (type a city or country to filter)",
+			Items:             regions,
+			Templates:         templates,
+			Size:              10,
+			Searcher:          searcher,
+			StartInSearchMode: true,
+		}
+
+		index, _, err := prompt.Run()
+		if err != nil {
+			return "", err
+		}
+
+		confirmed, err := confirmRegion(regions[index])
+		if err != nil {
+			return "", err
+		}
+		if confirmed {
+			return regions[index].Code, nil
+		}
 	}
+}
 
-	region, err := prompt.Run()
-	if err != nil {
-		return "", err
+// regionsByContinent orders regions by continent, keeping the catalog order
+// within a continent
+func regionsByContinent(regions []models.Region) []models.Region {
+	rank := func(region models.Region) int {
+		if i := slices.Index(models.Continents, region.Continent); i >= 0 {
+			return i
+		}
+		return len(models.Continents)
+	}
+
+	sorted := slices.Clone(regions)
+	slices.SortStableFunc(sorted, func(a, b models.Region) int {
+		return rank(a) - rank(b)
+	})
+	return sorted
+}
+
+// normalizeSearch folds case and separators, so "sao paulo" finds sao-paulo.americas
+func normalizeSearch(s string) string {
+	return strings.NewReplacer(" ", "", "-", "", ".", "").Replace(strings.ToLower(s))
+}
+
+// confirmRegion displays the region details and prompts the user to confirm or re-select
+func confirmRegion(region models.Region) (bool, error) {
+	regionStyle := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("#A9A9A9"))
+	fmt.Println("\n" + regionStyle.Render("Selected Region Details:\n"))
+	fmt.Printf("%s: %s\n", regionStyle.Render("Code"), region.Code)
+	fmt.Printf("%s: %s\n", regionStyle.Render("City"), region.City)
+	fmt.Printf("%s: %s\n", regionStyle.Render("Country"), region.Country)
+	fmt.Printf("%s: %s\n", regionStyle.Render("Continent"), region.Continent)
+	fmt.Println()
+
+	confirmPrompt := promptui.Select{
+		Label: "Do you want to probe from this region?",
+		Items: []string{"Confirm", "Re-select"},
 	}
 
-	return region, nil
+	_, result, err := confirmPrompt.Run()
+	if err != nil {
+		return false, fmt.Errorf("confirmation prompt failed: %w", err)
+	}
+	return result == "Confirm", nil
 }
 
-// promptNumberOfTargets prompts the user to enter the desired number of probe targets
-func promptNumberOfTargets() (int, error) {
+// promptNumberOfTargets prompts the user to enter the desired number of probe
+// targets, offering current unless it is zero
+func promptNumberOfTargets(current int) (int, error) {
 	validate := func(input string) error {
-		input = strings.TrimSpace(input)
-		if input == "" {
-			return fmt.Errorf("number of targets cannot be empty")
-		}
-		var number int
-		_, err := fmt.Sscanf(input, "%d", &number)
-		if err != nil || number <= 0 {
-			return fmt.Errorf("please enter a valid positive integer for the number of targets")
-		}
-		return nil
+		_, err := validateNumberOfTargets(input)
+		return err
 	}
 
 	prompt := promptui.Prompt{
 		Label:    "Enter the number of probe targets you wish to monitor",
 		Validate: validate,
 	}
+	if current > 0 {
+		prompt.Default = strconv.Itoa(current)
+	}
 
 	input, err := prompt.Run()
 	if err != nil {
 		return 0, err
 	}
 
-	var numberOfTargets int
-	_, err = fmt.Sscanf(strings.TrimSpace(input), "%d", &numberOfTargets)
-	if err != nil {
-		return 0, fmt.Errorf("failed to parse number of targets: %v", err)
-	}
-
-	return numberOfTargets, nil
+	return validateNumberOfTargets(input)
 }
 
 // sendSignupRequest sends the signup request to the API and returns the response
-func sendSignupRequest(req models.SignupRequest) (*models.SignupResponse, error) {
-	url := fmt.Sprintf("%s/sign-up", API_BASE_URL)
-
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal JSON: %v", err)
+func sendSignupRequest(ctx context.Context, apiClient *client.Client, req models.SignupRequest, idempotencyKey, output string) (*models.SignupResponse, error) {
+	// Inform the user that the request is being submitted, keeping
+	// machine-readable output clean
+	if output == "text" {
+		fmt.Println("\nSubmitting your sign-up request...")
 	}
 
-	// Inform the user that the request is being submitted
-	fmt.Println("\nSubmitting your sign-up request...")
-
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	signupResp, err := apiClient.SignUpIdempotent(ctx, req, idempotencyKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send HTTP request: %v", err)
+		return nil, fmt.Errorf("sign-up failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		var errorResp map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&errorResp); err != nil {
-			return nil, fmt.Errorf("sign-up failed with status: %s", resp.Status)
-		}
-		return nil, fmt.Errorf("sign-up failed: %v", errorResp)
-	}
+	return signupResp, nil
+}
 
-	var signupResp models.SignupResponse
-	if err := json.NewDecoder(resp.Body).Decode(&signupResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+// printSignupResponse saves the new account like displayResponse and prints
+// the sign-up response as JSON or YAML. Notes go to stderr.
+func printSignupResponse(resp *models.SignupResponse, file *models.ConfigFile, target *signupTarget, output string) error {
+	saved, configFile, err := saveSignupProfile(file, target, resp)
+	if err != nil {
+		warningStyle := lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#696969"))
+		fmt.Fprintf(os.Stderr, "%s: %v\n", warningStyle.Render("Warning"), err)
+	} else {
+		fmt.Fprintf(os.Stderr, "API key has been saved to profile %q in %s\n", saved, configFile)
 	}
 
-	return &signupResp, nil
+	return printStructured(output, resp)
 }
 
-// displayResponse displays the API response in a user-friendly format
-func displayResponse(resp *models.SignupResponse) {
+// displayResponse displays the API response in a user-friendly format and
+// saves the new account to the configuration file
+func displayResponse(resp *models.SignupResponse, file *models.ConfigFile, target *signupTarget) {
 	style := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#A9A9A9"))
@@ -286,48 +927,38 @@ func displayResponse(resp *models.SignupResponse) {
 	fmt.Printf("%s: %s\n", style.Render("Stripe URL"), resp.StripeURL)
 	fmt.Printf("%s: %s\n", style.Render("Plan Name"), resp.Plan.Name)
 
-	if resp.Plan.Name == "single-region" {
+	if resp.Plan.Region != "" {
 		fmt.Printf("%s: %s\n", style.Render("Region"), resp.Plan.Region)
 	}
 
-	config := &models.Config{
-		APIKey:    resp.APIKey,
-		AccountID: resp.AccountID,
-		Plan: models.SignupPlan{
-			Name:   resp.Plan.Name,
-			Region: resp.Plan.Region,
-		},
-		NumberOfTargets: resp.NumberOfTargets,
-	}
-
-	if err := SaveConfig(config); err != nil {
+	saved, configFile, err := saveSignupProfile(file, target, resp)
+	switch {
+	case err != nil:
 		errorStyle := lipgloss.NewStyle().
 			Bold(true).
 			Foreground(lipgloss.Color("#696969"))
 		fmt.Fprintf(os.Stderr, "%s: %v\n", errorStyle.Render("Warning"), err)
-	} else {
-		fmt.Println(style.Render("\nAPI key has been saved to ~/.gbx/config.yaml"))
+	case target.configOut != "":
+		fmt.Println(style.Render(fmt.Sprintf("\nAPI key has been saved to profile %q in %s", saved, configFile)))
+		fmt.Printf("Set GBX_CONFIG=%s to use it.\n", configFile)
+	default:
+		fmt.Println(style.Render(fmt.Sprintf("\nAPI key has been saved to profile %q in %s", saved, configFile)))
+		if saved != file.CurrentProfile {
+			fmt.Printf("Run 'gbx config use-profile %s' to make it the default, or pass --profile %s.\n", saved, saved)
+		}
 	}
 
 	nextStepsStyle := lipgloss.NewStyle().
 		Bold(true).
 		Foreground(lipgloss.Color("#D3D3D3"))
 	fmt.Println("\n" + nextStepsStyle.Render("Next Steps:"))
-	fmt.Println("1. Complete Subscription Payment by visiting the Stripe URL provided.")
+	fmt.Println("1. Complete Subscription Payment by visiting the Stripe URL provided, then run 'gbx account status --wait'.")
 	fmt.Println("2. Secure your API Key for authenticating your Prometheus scrape jobs.")
-	fmt.Println("3. Configure Prometheus with your account details. Refer to the Prometheus Configuration documentation for guidance.\n")
+	fmt.Println("3. Configure Prometheus with your account details. Refer to the Prometheus Configuration documentation for guidance.")
+	fmt.Println()
 
 	supportStyle := lipgloss.NewStyle().
 		Italic(true).
 		Foreground(lipgloss.Color("#A9A9A9")) // Medium Grey
 	fmt.Println(supportStyle.Render("For support, contact support@globalblackbox.io"))
 }
-
-// exitWithError prints the error and exits the application
-func exitWithError(err error) {
-	errorStyle := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("#696969"))
-	fmt.Fprintf(os.Stderr, "%s: %v\n", errorStyle.Render("Error"), err)
-	os.Exit(1)
-}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, injected by goreleaser with
+// -ldflags "-X globalblackbox.io/gbx/cmd.version=... -X ...cmd.commit=... -X ...cmd.date=..."
+var (
+	version = "dev"
+	commit  = "none"
+	date    = "unknown"
+)
+
+// versionInfo is the output of the version command
+type versionInfo struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit" yaml:"commit"`
+	Date      string `json:"date" yaml:"date"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	Platform  string `json:"platform" yaml:"platform"`
+}
+
+// Define the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the gbx version and build information",
+	Long:  `Print the gbx version, the commit and date it was built from, and the Go version used to build it.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runVersion(cmd, args)
+	},
+}
+
+func init() {
+	versionCmd.Flags().StringP("output", "o", "text", "Output format (text, json or yaml)")
+}
+
+// runVersion handles the 'version' command
+func runVersion(cmd *cobra.Command, args []string) error {
+	output, _ := cmd.Flags().GetString("output")
+	if err := validateOutput(output, "text", "json", "yaml"); err != nil {
+		return err
+	}
+
+	info := buildInfo()
+	if output != "text" {
+		return printStructured(output, info)
+	}
+
+	fmt.Printf("gbx version %s\n", info.Version)
+	fmt.Printf("commit: %s\n", info.Commit)
+	fmt.Printf("built: %s\n", info.Date)
+	fmt.Printf("go: %s %s\n", info.GoVersion, info.Platform)
+	return nil
+}
+
+// buildInfo returns the build metadata. Binaries not built by goreleaser,
+// e.g. with go install, fall back to the module and VCS information
+// recorded by the Go toolchain.
+func buildInfo() versionInfo {
+	info := versionInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "none":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "unknown":
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// userAgent is the User-Agent header sent with every API request
+func userAgent() string {
+	return "gbx/" + buildInfo().Version
+}
@@ -0,0 +1,28 @@
+// Package credentials keeps Global Blackbox API keys out of the plaintext
+// configuration file, either in a passphrase-encrypted file or in an
+// external credential helper program.
+package credentials
+
+import "errors"
+
+// ErrNotFound is returned by Store.Get when no API key is stored for a profile
+var ErrNotFound = errors.New("no stored API key")
+
+// Profile identifies the account an API key belongs to
+type Profile struct {
+	Name      string
+	AccountID string
+	APIURL    string
+}
+
+// Store keeps API keys by profile
+type Store interface {
+	// Get returns the API key of the profile, or ErrNotFound
+	Get(profile Profile) (string, error)
+
+	// Store saves the API key of the profile, replacing any previous one
+	Store(profile Profile, apiKey string) error
+
+	// Erase removes the API key of the profile. Erasing a missing key is not an error.
+	Erase(profile Profile) error
+}
@@ -0,0 +1,190 @@
+package credentials
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// scrypt parameters for new files, as recommended for interactive logins
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// EncryptedFile is a Store keeping the API keys of all profiles in a single
+// file, encrypted with AES-256-GCM under a key derived from a passphrase
+// with scrypt
+type EncryptedFile struct {
+	// Path of the encrypted file
+	Path string
+
+	// Passphrase returns the passphrase protecting the file
+	Passphrase func() (string, error)
+}
+
+// encryptedFile is the on-disk format of an EncryptedFile
+type encryptedFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	N          int    `json:"n"`
+	R          int    `json:"r"`
+	P          int    `json:"p"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Get implements Store
+func (f *EncryptedFile) Get(profile Profile) (string, error) {
+	keys, err := f.read()
+	if err != nil {
+		return "", err
+	}
+
+	apiKey, exists := keys[profile.Name]
+	if !exists {
+		return "", ErrNotFound
+	}
+	return apiKey, nil
+}
+
+// Store implements Store
+func (f *EncryptedFile) Store(profile Profile, apiKey string) error {
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	keys[profile.Name] = apiKey
+	return f.write(keys)
+}
+
+// Erase implements Store
+func (f *EncryptedFile) Erase(profile Profile) error {
+	keys, err := f.read()
+	if err != nil {
+		return err
+	}
+
+	if _, exists := keys[profile.Name]; !exists {
+		return nil
+	}
+	delete(keys, profile.Name)
+	return f.write(keys)
+}
+
+// read decrypts the file. A missing file holds no keys.
+func (f *EncryptedFile) read() (map[string]string, error) {
+	data, err := os.ReadFile(f.Path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %v", err)
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %v", err)
+	}
+	if file.Version != 1 || file.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported credentials file format (version %d, kdf %q)", file.Version, file.KDF)
+	}
+
+	aead, err := f.cipher(file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := aead.Open(nil, file.Nonce, file.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials file: wrong passphrase or corrupted file")
+	}
+
+	keys := map[string]string{}
+	if err := json.Unmarshal(plaintext, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse decrypted credentials: %v", err)
+	}
+	return keys, nil
+}
+
+// write encrypts keys with a fresh salt and nonce and replaces the file
+func (f *EncryptedFile) write(keys map[string]string) error {
+	plaintext, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %v", err)
+	}
+
+	file := encryptedFile{Version: 1, KDF: "scrypt", N: scryptN, R: scryptR, P: scryptP}
+	file.Salt = make([]byte, 16)
+	if _, err := rand.Read(file.Salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	aead, err := f.cipher(file.Salt, file.N, file.R, file.P)
+	if err != nil {
+		return err
+	}
+
+	file.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(file.Nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	file.Ciphertext = aead.Seal(nil, file.Nonce, plaintext, nil)
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials file: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), "."+filepath.Base(f.Path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write credentials file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write credentials file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write credentials file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("failed to write credentials file: %v", err)
+	}
+	return nil
+}
+
+// cipher derives the AES-256-GCM cipher from the passphrase
+func (f *EncryptedFile) cipher(salt []byte, n, r, p int) (cipher.AEAD, error) {
+	passphrase, err := f.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase cannot be empty")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive encryption key: %v", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
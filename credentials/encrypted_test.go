@@ -0,0 +1,61 @@
+package credentials
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// passphrase returns a Passphrase function answering with value
+func passphrase(value string) func() (string, error) {
+	return func() (string, error) { return value, nil }
+}
+
+func TestEncryptedFileRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store := &EncryptedFile{Path: path, Passphrase: passphrase("correct horse")}
+
+	keys := map[string]string{"default": "gbx-key-1", "staging": "gbx-key-2"}
+	for name, apiKey := range keys {
+		if err := store.Store(Profile{Name: name}, apiKey); err != nil {
+			t.Fatalf("Store(%s) error = %v", name, err)
+		}
+	}
+
+	reopened := &EncryptedFile{Path: path, Passphrase: passphrase("correct horse")}
+	for name, want := range keys {
+		if got, err := reopened.Get(Profile{Name: name}); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	if err := reopened.Erase(Profile{Name: "staging"}); err != nil {
+		t.Fatalf("Erase() error = %v", err)
+	}
+	if _, err := reopened.Get(Profile{Name: "staging"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Erase() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestEncryptedFilePassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials.enc")
+	store := &EncryptedFile{Path: path, Passphrase: passphrase("correct horse")}
+	if err := store.Store(Profile{Name: "default"}, "gbx-key-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		passphrase string
+		wantErr    string
+	}{
+		{"battery staple", "wrong passphrase"},
+		{"", "passphrase cannot be empty"},
+	}
+	for _, tt := range tests {
+		store := &EncryptedFile{Path: path, Passphrase: passphrase(tt.passphrase)}
+		if _, err := store.Get(Profile{Name: "default"}); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("Get() with passphrase %q error = %v, want %q", tt.passphrase, err, tt.wantErr)
+		}
+	}
+}
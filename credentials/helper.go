@@ -0,0 +1,101 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Helper is a Store backed by an external program, in the style of git
+// credential helpers. The program is run with one of the actions "get",
+// "store" or "erase" as its argument and receives the profile as key=value
+// lines on stdin, terminated by a blank line:
+//
+//	profile=production
+//	account_id=acc-1234
+//	api_url=https://api.globalblackbox.io
+//	api_key=...            (store only)
+//
+// For "get" it prints api_key=... on stdout, or nothing if it has no key.
+// A non-zero exit status is reported as an error.
+type Helper struct {
+	// Command names the helper. A bare name such as "pass-gbx" runs
+	// gbx-credential-pass-gbx if it is on the PATH, and pass-gbx otherwise.
+	// A path is run as is, and a value starting with "!" is run by the shell.
+	Command string
+}
+
+// Get implements Store
+func (h *Helper) Get(profile Profile) (string, error) {
+	out, err := h.run("get", profile, "")
+	if err != nil {
+		return "", err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), "="); ok && key == "api_key" && value != "" {
+			return value, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// Store implements Store
+func (h *Helper) Store(profile Profile, apiKey string) error {
+	_, err := h.run("store", profile, apiKey)
+	return err
+}
+
+// Erase implements Store
+func (h *Helper) Erase(profile Profile) error {
+	_, err := h.run("erase", profile, "")
+	return err
+}
+
+// run invokes the helper with the action and returns its stdout
+func (h *Helper) run(action string, profile Profile, apiKey string) ([]byte, error) {
+	var cmd *exec.Cmd
+	switch {
+	case strings.HasPrefix(h.Command, "!"):
+		cmd = exec.Command("sh", "-c", h.Command[1:]+" \"$@\"", h.Command[1:], action)
+	case strings.ContainsRune(h.Command, filepath.Separator):
+		cmd = exec.Command(h.Command, action)
+	default:
+		name := h.Command
+		if path, err := exec.LookPath("gbx-credential-" + h.Command); err == nil {
+			name = path
+		}
+		cmd = exec.Command(name, action)
+	}
+
+	var input strings.Builder
+	fmt.Fprintf(&input, "profile=%s\n", profile.Name)
+	if profile.AccountID != "" {
+		fmt.Fprintf(&input, "account_id=%s\n", profile.AccountID)
+	}
+	if profile.APIURL != "" {
+		fmt.Fprintf(&input, "api_url=%s\n", profile.APIURL)
+	}
+	if apiKey != "" {
+		fmt.Fprintf(&input, "api_key=%s\n", apiKey)
+	}
+	input.WriteString("\n")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, fmt.Errorf("credential helper %q failed to %s the API key: %s", h.Command, action, msg)
+	}
+	return stdout.Bytes(), nil
+}
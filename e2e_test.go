@@ -0,0 +1,1443 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"globalblackbox.io/gbx/client"
+	"globalblackbox.io/gbx/fakeapi"
+	"globalblackbox.io/gbx/models"
+	"gopkg.in/yaml.v2"
+)
+
+// gbxBinary is the gbx executable built once for all end-to-end tests
+var gbxBinary string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "gbx-e2e")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	gbxBinary = filepath.Join(dir, "gbx")
+	if out, err := exec.Command("go", "build", "-o", gbxBinary, ".").CombinedOutput(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to build gbx: %v\n%s", err, out)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testEnv is an isolated home and working directory for running gbx against a fake API
+type testEnv struct {
+	t       *testing.T
+	api     *fakeapi.Server
+	url     string
+	home    string
+	workDir string
+
+	// env holds extra environment variables, e.g. "GBX_PROFILE=staging"
+	env []string
+}
+
+// result is the outcome of a gbx invocation
+type result struct {
+	stdout   string
+	stderr   string
+	exitCode int
+}
+
+// newTestEnv starts a fake API and writes a config file holding apiKey,
+// unless apiKey is empty
+func newTestEnv(t *testing.T, apiKey string) *testEnv {
+	t.Helper()
+
+	api := fakeapi.New()
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	env := &testEnv{t: t, api: api, url: srv.URL, home: t.TempDir(), workDir: t.TempDir()}
+	if apiKey != "" {
+		env.writeConfig(fmt.Sprintf("api_key: %s\naccount_id: %s\n", apiKey, fakeapi.FixtureAccountID))
+	}
+	return env
+}
+
+// writeConfig replaces the content of ~/.gbx/config.yaml
+func (e *testEnv) writeConfig(content string) {
+	e.t.Helper()
+
+	configDir := filepath.Join(e.home, ".gbx")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		e.t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0600); err != nil {
+		e.t.Fatal(err)
+	}
+}
+
+// configDir returns the configuration directory gbx uses: the legacy ~/.gbx
+// when it exists, ~/.config/gbx otherwise
+func (e *testEnv) configDir() string {
+	if legacyDir := filepath.Join(e.home, ".gbx"); dirExists(legacyDir) {
+		return legacyDir
+	}
+	return filepath.Join(e.home, ".config", "gbx")
+}
+
+// dirExists reports whether path is an existing directory
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// readConfig returns the content of the configuration file
+func (e *testEnv) readConfig() string {
+	e.t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(e.configDir(), "config.yaml"))
+	if err != nil {
+		e.t.Fatal(err)
+	}
+	return string(data)
+}
+
+// run executes gbx with args and returns its output and exit code
+func (e *testEnv) run(args ...string) result {
+	e.t.Helper()
+
+	cmd := exec.Command(gbxBinary, args...)
+	cmd.Dir = e.workDir
+	cmd.Env = append([]string{
+		"HOME=" + e.home,
+		"PATH=" + os.Getenv("PATH"),
+		"GBX_API_URL=" + e.url,
+	}, e.env...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	res := result{stdout: stdout.String(), stderr: stderr.String()}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		res.exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		e.t.Fatalf("failed to run gbx: %v", err)
+	}
+	return res
+}
+
+// fixtureLogArgs are the flags selecting the fixture log files
+var fixtureLogArgs = []string{
+	"--region", fakeapi.FixtureRegion,
+	"--target_domain", fakeapi.FixtureTargetDomain,
+	"--date", fakeapi.FixtureDate,
+}
+
+func fixtureLogName(hour string) string {
+	return fmt.Sprintf("probe-failures-%sT%s-00-00Z.log", fakeapi.FixtureDate, hour)
+}
+
+func TestLogsList(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run(append([]string{"logs", "list"}, fixtureLogArgs...)...)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	for i, hour := range []string{"03", "09", "17"} {
+		want := fmt.Sprintf("%d. %s", i+1, fixtureLogName(hour))
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output does not list %q:\n%s", want, res.stdout)
+		}
+	}
+}
+
+func TestLogsListLimit(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run(append([]string{"logs", "list", "--limit", "2"}, fixtureLogArgs...)...)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if strings.Contains(res.stdout, fixtureLogName("17")) {
+		t.Errorf("output lists more than 2 files:\n%s", res.stdout)
+	}
+}
+
+func TestLogsListEmpty(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run("logs", "list", "-r", fakeapi.FixtureRegion, "-t", fakeapi.FixtureTargetDomain, "-d", "2024-10-02")
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "No log files found") {
+		t.Errorf("unexpected output:\n%s", res.stdout)
+	}
+}
+
+func TestLogsListInvalidDate(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run("logs", "list", "-r", fakeapi.FixtureRegion, "-t", fakeapi.FixtureTargetDomain, "-d", "01/10/2024")
+	if res.exitCode != 1 || !strings.Contains(res.stderr, "invalid date format") {
+		t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if len(env.api.Requests()) != 0 {
+		t.Errorf("API was called: %v", env.api.Requests())
+	}
+}
+
+func TestLogsDownload(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	name := fixtureLogName("09")
+
+	res := env.run(append([]string{"logs", "download", "--fileName", name}, fixtureLogArgs...)...)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	data, err := os.ReadFile(filepath.Join(env.workDir, "logs", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "status_code=503") {
+		t.Errorf("unexpected log content:\n%s", data)
+	}
+}
+
+func TestLogsDownloadNotFound(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run(append([]string{"logs", "download", "-f", "missing.log"}, fixtureLogArgs...)...)
+	if res.exitCode != 4 {
+		t.Fatalf("exit code = %d, want 4, stderr: %s", res.exitCode, res.stderr)
+	}
+	if !strings.Contains(res.stderr, "log file not found") {
+		t.Errorf("stderr does not contain the API message: %s", res.stderr)
+	}
+	assertNoLogFiles(t, env)
+}
+
+func TestMissingConfig(t *testing.T) {
+	env := newTestEnv(t, "")
+
+	res := env.run(append([]string{"logs", "list"}, fixtureLogArgs...)...)
+	if res.exitCode != 1 || !strings.Contains(res.stderr, "config file not found") {
+		t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiKey   string
+		fault    *fakeapi.Fault
+		wantCode int
+	}{
+		{name: "bad API key", apiKey: "wrong-key", wantCode: 3},
+		{name: "unauthorized", fault: &fakeapi.Fault{Status: 401}, wantCode: 3},
+		{name: "quota exceeded", fault: &fakeapi.Fault{Status: 402}, wantCode: 5},
+		{name: "rate limited", fault: &fakeapi.Fault{Status: 429}, wantCode: 6},
+		{name: "server error", fault: &fakeapi.Fault{Status: 500}, wantCode: 7},
+		{name: "bad gateway", fault: &fakeapi.Fault{Status: 502}, wantCode: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiKey := tt.apiKey
+			if apiKey == "" {
+				apiKey = fakeapi.FixtureAPIKey
+			}
+			env := newTestEnv(t, apiKey)
+			if tt.fault != nil {
+				env.api.InjectFault(*tt.fault)
+			}
+
+			res := env.run(append([]string{"logs", "list", "--max-retries", "0"}, fixtureLogArgs...)...)
+			if res.exitCode != tt.wantCode {
+				t.Errorf("exit code = %d, want %d, stderr: %s", res.exitCode, tt.wantCode, res.stderr)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	env.api.InjectFault(fakeapi.Fault{Path: "/logs", Status: 503, Times: 1})
+	env.api.InjectFault(fakeapi.Fault{Path: "/logs", Status: 429, Times: 1, RetryAfter: "0"})
+
+	res := env.run(append([]string{"logs", "list"}, fixtureLogArgs...)...)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if n := len(env.api.Requests()); n != 3 {
+		t.Errorf("got %d requests, want 3", n)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	env.api.InjectFault(fakeapi.Fault{Delay: 5 * time.Second})
+
+	res := env.run(append([]string{"logs", "download", "-f", fixtureLogName("03"), "--timeout", "200ms"}, fixtureLogArgs...)...)
+	if res.exitCode != 124 {
+		t.Fatalf("exit code = %d, want 124, stderr: %s", res.exitCode, res.stderr)
+	}
+	assertNoLogFiles(t, env)
+}
+
+func TestLatencyWithFaults(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	env.api.SetLatency(time.Second)
+	env.api.InjectFault(fakeapi.Fault{Status: 500})
+
+	// The failing request is slowed down too, so it times out first
+	res := env.run(append([]string{"logs", "list", "--max-retries", "0", "--timeout", "200ms"}, fixtureLogArgs...)...)
+	if res.exitCode != 124 {
+		t.Errorf("exit code = %d, want 124, stderr: %s", res.exitCode, res.stderr)
+	}
+}
+
+func TestDebugRedactsAPIKey(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run(append([]string{"logs", "list", "--debug"}, fixtureLogArgs...)...)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if !strings.Contains(res.stderr, "--> GET "+env.url+"/logs?") {
+		t.Errorf("request not traced:\n%s", res.stderr)
+	}
+	if strings.Contains(res.stderr, fakeapi.FixtureAPIKey) || !strings.Contains(res.stderr, "[REDACTED]") {
+		t.Errorf("API key not redacted:\n%s", res.stderr)
+	}
+}
+
+// profilesConfig has a staging profile with the fixture key and a broken production profile
+const profilesConfig = `current_profile: production
+profiles:
+  production:
+    api_key: revoked-key
+  staging:
+    api_key: gbx-test-key
+    account_id: acc-test-0001
+    plan:
+      name: single-region
+`
+
+func TestProfiles(t *testing.T) {
+	env := newTestEnv(t, "")
+	env.writeConfig(profilesConfig)
+	listArgs := append([]string{"logs", "list"}, fixtureLogArgs...)
+
+	if res := env.run(listArgs...); res.exitCode != 3 {
+		t.Errorf("current profile: exit code = %d, want 3, stderr: %s", res.exitCode, res.stderr)
+	}
+	if res := env.run(append(listArgs, "--profile", "staging")...); res.exitCode != 0 {
+		t.Errorf("--profile staging: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	env.env = []string{"GBX_PROFILE=staging"}
+	if res := env.run(listArgs...); res.exitCode != 0 {
+		t.Errorf("GBX_PROFILE=staging: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if res := env.run(append(listArgs, "--profile", "missing")...); !strings.Contains(res.stderr, `profile "missing" not found`) {
+		t.Errorf("--profile missing: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	env.env = nil
+
+	res := env.run("config", "list-profiles")
+	if !strings.Contains(res.stdout, "* production") || !strings.Contains(res.stdout, "acc-test-0001") {
+		t.Errorf("unexpected list-profiles output:\n%s", res.stdout)
+	}
+
+	if res := env.run("config", "use-profile", "staging"); res.exitCode != 0 {
+		t.Fatalf("use-profile: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if res := env.run(listArgs...); res.exitCode != 0 {
+		t.Errorf("after use-profile: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if !strings.Contains(env.readConfig(), "revoked-key") {
+		t.Errorf("use-profile lost the production profile:\n%s", env.readConfig())
+	}
+
+	if res := env.run("config", "use-profile", "missing"); res.exitCode != 1 {
+		t.Errorf("use-profile missing: exit code = %d", res.exitCode)
+	}
+}
+
+func TestConfigMigration(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	legacy := env.readConfig()
+
+	res := env.run("config", "list-profiles")
+	if !strings.Contains(res.stdout, "* default") {
+		t.Errorf("legacy config not listed as the default profile:\n%s", res.stdout)
+	}
+	if !strings.Contains(res.stderr, "upgraded") {
+		t.Errorf("no migration note:\n%s", res.stderr)
+	}
+
+	migrated := env.readConfig()
+	if !strings.Contains(migrated, "version: 2") || !strings.Contains(migrated, "profiles:") {
+		t.Errorf("config file not migrated:\n%s", migrated)
+	}
+
+	backups, _ := filepath.Glob(filepath.Join(env.configDir(), "config.yaml.v1-*.bak"))
+	if len(backups) != 1 {
+		t.Fatalf("got backups %v, want one", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != legacy {
+		t.Errorf("backup does not hold the original file:\n%s", data)
+	}
+
+	// Migrated files are left alone afterwards
+	if res := env.run("config", "list-profiles"); strings.Contains(res.stderr, "upgraded") {
+		t.Errorf("config file migrated twice:\n%s", res.stderr)
+	}
+
+	env.writeConfig("version: 99\n")
+	if res := env.run("config", "list-profiles"); res.exitCode != 1 || !strings.Contains(res.stderr, "please upgrade gbx") {
+		t.Errorf("newer version: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+}
+
+func TestReadOnlyLegacyConfig(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions do not apply to root")
+	}
+
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	configDir := filepath.Join(env.home, ".gbx")
+	os.Chmod(filepath.Join(configDir, "config.yaml"), 0400)
+	os.Chmod(configDir, 0500)
+	t.Cleanup(func() { os.Chmod(configDir, 0700) })
+
+	// Reading commands use the legacy file as is
+	res := env.run("config", "list-profiles")
+	if res.exitCode != 0 || !strings.Contains(res.stdout, "* default") {
+		t.Fatalf("exit code = %d, stdout: %s, stderr: %s", res.exitCode, res.stdout, res.stderr)
+	}
+	if !strings.Contains(res.stderr, "could not be upgraded") {
+		t.Errorf("no warning:\n%s", res.stderr)
+	}
+	if res := env.run(append([]string{"logs", "list"}, fixtureLogArgs...)...); res.exitCode != 0 {
+		t.Errorf("logs list: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	// Writing commands fail
+	if res := env.run("config", "set", "plan.name", "worldwide"); res.exitCode != 1 {
+		t.Errorf("config set: exit code = %d, want 1", res.exitCode)
+	}
+	if backups, _ := filepath.Glob(filepath.Join(configDir, "*.bak")); len(backups) > 0 {
+		t.Errorf("backups written: %v", backups)
+	}
+}
+
+func TestXDGConfigHome(t *testing.T) {
+	env := newTestEnv(t, "")
+
+	// A new file goes to the XDG default without XDG_CONFIG_HOME
+	if res := env.run("auth", "login", "--api-key", fakeapi.FixtureAPIKey); res.exitCode != 0 {
+		t.Fatalf("login: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if _, err := os.Stat(filepath.Join(env.home, ".config", "gbx", "config.yaml")); err != nil {
+		t.Errorf("config file not created in ~/.config/gbx: %v", err)
+	}
+	if dirExists(filepath.Join(env.home, ".gbx")) {
+		t.Errorf("legacy ~/.gbx created")
+	}
+
+	env = newTestEnv(t, "")
+	xdgHome := t.TempDir()
+	env.env = []string{"XDG_CONFIG_HOME=" + xdgHome}
+
+	res := env.run("config", "path")
+	if want := filepath.Join(xdgHome, "gbx", "config.yaml"); strings.TrimSpace(res.stdout) != want {
+		t.Errorf("config path = %q, want %q", res.stdout, want)
+	}
+
+	// An existing legacy file keeps being used
+	env.writeConfig("api_key: " + fakeapi.FixtureAPIKey + "\n")
+	res = env.run("config", "path")
+	if want := filepath.Join(env.configDir(), "config.yaml"); strings.TrimSpace(res.stdout) != want {
+		t.Errorf("config path = %q, want the legacy %q", res.stdout, want)
+	}
+}
+
+func TestConfigCommands(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	configFile := filepath.Join(env.configDir(), "config.yaml")
+	os.Chmod(configFile, 0644)
+
+	if res := env.run("config", "path"); strings.TrimSpace(res.stdout) != configFile {
+		t.Errorf("config path = %q, want %q", res.stdout, configFile)
+	}
+
+	res := env.run("config", "view")
+	if strings.Contains(res.stdout, fakeapi.FixtureAPIKey) || !strings.Contains(res.stdout, "account_id: "+fakeapi.FixtureAccountID) {
+		t.Errorf("unexpected config view output:\n%s", res.stdout)
+	}
+	if res := env.run("config", "get", "api_key", "--show-secrets"); strings.TrimSpace(res.stdout) != fakeapi.FixtureAPIKey {
+		t.Errorf("config get api_key --show-secrets = %q", res.stdout)
+	}
+
+	for _, args := range [][]string{
+		{"plan.name", "enterprise"},
+		{"plan.region", "londn.europe"},
+		{"number_of_targets", "-1"},
+		{"tls_min_version", "1.0"},
+		{"ca_bundles", filepath.Join(env.home, "missing.pem")},
+		{"ca_bundles", " , "},
+		{"no_such_key", "x"},
+	} {
+		if res := env.run(append([]string{"config", "set"}, args...)...); res.exitCode != 1 {
+			t.Errorf("config set %v: exit code = %d, want 1", args, res.exitCode)
+		}
+	}
+
+	if res := env.run("config", "set", "plan.region", "tokyo.asia"); res.exitCode != 0 {
+		t.Fatalf("config set: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if res := env.run("config", "get", "plan.region"); strings.TrimSpace(res.stdout) != "tokyo.asia" {
+		t.Errorf("config get plan.region = %q", res.stdout)
+	}
+	if res := env.run("config", "unset", "plan.region"); res.exitCode != 0 {
+		t.Fatalf("config unset: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if strings.Contains(env.readConfig(), "tokyo.asia") {
+		t.Errorf("plan.region not removed:\n%s", env.readConfig())
+	}
+
+	bundle := filepath.Join(env.home, "ca.pem")
+	if err := os.WriteFile(bundle, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if res := env.run("config", "set", "ca_bundles", " "+bundle+", ,"+bundle+" "); res.exitCode != 0 {
+		t.Fatalf("config set ca_bundles: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if res := env.run("config", "get", "ca_bundles"); strings.TrimSpace(res.stdout) != bundle+","+bundle {
+		t.Errorf("config get ca_bundles = %q", res.stdout)
+	}
+
+	info, err := os.Stat(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("config file permissions = %o, want 600", perm)
+	}
+}
+
+func TestEnvironmentCredentials(t *testing.T) {
+	listArgs := append([]string{"logs", "list"}, fixtureLogArgs...)
+
+	t.Run("GBX_API_KEY without config file", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.env = []string{"GBX_API_KEY=" + fakeapi.FixtureAPIKey}
+		if res := env.run(listArgs...); res.exitCode != 0 {
+			t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if _, err := os.Stat(env.configDir()); !os.IsNotExist(err) {
+			t.Errorf("config directory was created")
+		}
+	})
+
+	t.Run("GBX_API_KEY overrides config file", func(t *testing.T) {
+		env := newTestEnv(t, "revoked-key")
+		env.env = []string{"GBX_API_KEY=" + fakeapi.FixtureAPIKey}
+		if res := env.run(listArgs...); res.exitCode != 0 {
+			t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+	})
+
+	t.Run("--api-key-file overrides GBX_API_KEY", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		keyFile := filepath.Join(t.TempDir(), "api-key")
+		os.WriteFile(keyFile, []byte(fakeapi.FixtureAPIKey+"\n"), 0600)
+		env.env = []string{"GBX_API_KEY=revoked-key"}
+		if res := env.run(append(listArgs, "--api-key-file", keyFile)...); res.exitCode != 0 {
+			t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+	})
+
+	t.Run("GBX_CONFIG", func(t *testing.T) {
+		env := newTestEnv(t, "revoked-key")
+		configFile := filepath.Join(t.TempDir(), "ci", "gbx.yaml")
+		env.env = []string{"GBX_CONFIG=" + configFile}
+		if res := env.run("config", "set", "api_key", fakeapi.FixtureAPIKey); res.exitCode != 0 {
+			t.Fatalf("config set: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if res := env.run(listArgs...); res.exitCode != 0 {
+			t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if strings.Contains(env.readConfig(), fakeapi.FixtureAPIKey) {
+			t.Errorf("default config file was modified")
+		}
+	})
+}
+
+func TestEncryptedCredentials(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	env.env = []string{"GBX_PASSPHRASE=correct horse battery staple"}
+	listArgs := append([]string{"logs", "list"}, fixtureLogArgs...)
+
+	if res := env.run("config", "set", "credential_store", "encrypted"); res.exitCode != 0 {
+		t.Fatalf("config set: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if strings.Contains(env.readConfig(), fakeapi.FixtureAPIKey) {
+		t.Errorf("API key still in plaintext:\n%s", env.readConfig())
+	}
+	encrypted, err := os.ReadFile(filepath.Join(env.configDir(), "credentials.enc"))
+	if err != nil || strings.Contains(string(encrypted), fakeapi.FixtureAPIKey) {
+		t.Errorf("API key not encrypted (err %v):\n%s", err, encrypted)
+	}
+
+	if res := env.run(listArgs...); res.exitCode != 0 {
+		t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	env.env = []string{"GBX_PASSPHRASE=wrong"}
+	if res := env.run(listArgs...); res.exitCode != 1 || !strings.Contains(res.stderr, "wrong passphrase") {
+		t.Errorf("wrong passphrase: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+}
+
+func TestCredentialHelper(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	listArgs := append([]string{"logs", "list"}, fixtureLogArgs...)
+
+	// A helper keeping the key in a file, logging the actions it was asked for
+	dir := t.TempDir()
+	helper := filepath.Join(dir, "gbx-credential-file")
+	script := `#!/bin/sh
+echo "$1" >> "` + dir + `/actions"
+case "$1" in
+  get) cat "` + dir + `/key" 2>/dev/null || true ;;
+  store) grep '^api_key=' > "` + dir + `/key" ;;
+  erase) rm -f "` + dir + `/key" ;;
+esac
+`
+	if err := os.WriteFile(helper, []byte(script), 0700); err != nil {
+		t.Fatal(err)
+	}
+	env.env = []string{"PATH=" + dir + string(os.PathListSeparator) + os.Getenv("PATH")}
+
+	if res := env.run("config", "set", "credential_helper", "file"); res.exitCode != 0 {
+		t.Fatalf("config set: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if strings.Contains(env.readConfig(), fakeapi.FixtureAPIKey) {
+		t.Errorf("API key still in plaintext:\n%s", env.readConfig())
+	}
+	if res := env.run(listArgs...); res.exitCode != 0 {
+		t.Errorf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	if res := env.run("config", "unset", "api_key"); res.exitCode != 0 {
+		t.Fatalf("config unset: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if res := env.run(listArgs...); res.exitCode != 1 || !strings.Contains(res.stderr, "API key not found") {
+		t.Errorf("after erase: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	actions, _ := os.ReadFile(filepath.Join(dir, "actions"))
+	if got := strings.Fields(string(actions)); strings.Join(got, " ") != "store get erase get" {
+		t.Errorf("helper actions = %v", got)
+	}
+}
+
+func TestVersion(t *testing.T) {
+	env := newTestEnv(t, "")
+
+	res := env.run("version", "--output", "json")
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	var info struct {
+		Version   string `json:"version"`
+		GoVersion string `json:"go_version"`
+	}
+	if err := json.Unmarshal([]byte(res.stdout), &info); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, res.stdout)
+	}
+	if info.Version == "" || !strings.HasPrefix(info.GoVersion, "go") {
+		t.Errorf("incomplete version info: %+v", info)
+	}
+
+	if res := env.run("version", "-o", "yaml"); res.exitCode != 0 || !strings.Contains(res.stdout, "go_version: go") {
+		t.Errorf("yaml output: exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+	}
+	if res := env.run("version", "-o", "xml"); res.exitCode != 1 || !strings.Contains(res.stderr, `unsupported output format "xml": use text, json or yaml`) {
+		t.Errorf("invalid format: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+}
+
+func TestUserAgentAndDeprecationWarning(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	env.api.SetClientStatus("deprecated", "support ends on 2025-01-01")
+
+	res := env.run(append([]string{"logs", "list"}, fixtureLogArgs...)...)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if ua := env.api.RequestHeaders()[0].Get("User-Agent"); !strings.HasPrefix(ua, "gbx/") {
+		t.Errorf("User-Agent = %q, want gbx/<version>", ua)
+	}
+	if !strings.Contains(res.stderr, "deprecated") || !strings.Contains(res.stderr, "support ends on 2025-01-01") {
+		t.Errorf("no deprecation warning:\n%s", res.stderr)
+	}
+}
+
+func TestDevFakeAPI(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	fake := exec.Command(gbxBinary, "dev", "fake-api", "--listen", "127.0.0.1:0")
+	stdout, err := fake.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fake.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer fake.Process.Kill()
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.url = strings.TrimSpace(line[strings.Index(line, "http://"):])
+
+	res := env.run(append([]string{"logs", "list"}, fixtureLogArgs...)...)
+	if res.exitCode != 0 || !strings.Contains(res.stdout, fixtureLogName("03")) {
+		t.Errorf("exit code = %d, stdout: %s, stderr: %s", res.exitCode, res.stdout, res.stderr)
+	}
+
+	fake.Process.Signal(os.Interrupt)
+	if err := fake.Wait(); err != nil {
+		t.Errorf("fake API did not shut down cleanly: %v", err)
+	}
+}
+
+// TestSignUp exercises the sign-up API through the client, since the sign-up
+// command itself is driven by interactive prompts
+func TestSignUp(t *testing.T) {
+	srv := httptest.NewServer(fakeapi.New())
+	defer srv.Close()
+	c := client.New(client.WithBaseURL(srv.URL))
+
+	resp, err := c.SignUp(context.Background(), models.SignupRequest{
+		Email: "new@example.com",
+		Plan:  models.SignupPlan{Name: "single-region", Region: "tokyo.asia", NumberOfTargets: 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.APIKey == "" || resp.AccountID == "" || resp.StripeURL == "" {
+		t.Errorf("incomplete sign-up response: %+v", resp)
+	}
+
+	// The new key works against the authenticated endpoints
+	_, err = client.New(client.WithBaseURL(srv.URL), client.WithAPIKey(resp.APIKey)).
+		ListLogs(context.Background(), models.LogsQuery{Region: "tokyo.asia", TargetDomain: "example.com", Date: "2024-10-01"})
+	if err != nil {
+		t.Errorf("listing logs with the new API key: %v", err)
+	}
+
+	_, err = c.SignUp(context.Background(), models.SignupRequest{
+		Email: "new@example.com",
+		Plan:  models.SignupPlan{Name: "single-region", NumberOfTargets: 5},
+	})
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_region" {
+		t.Errorf("got %v, want an invalid_region API error", err)
+	}
+
+	// Repeating a sign-up with its idempotency key returns the same account
+	req := models.SignupRequest{Email: "again@example.com", Plan: models.SignupPlan{Name: "worldwide", NumberOfTargets: 2}}
+	key := client.NewIdempotencyKey()
+	first, err := c.SignUpIdempotent(context.Background(), req, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.SignUpIdempotent(context.Background(), req, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.AccountID != second.AccountID {
+		t.Errorf("repeated sign-up created account %s after %s", second.AccountID, first.AccountID)
+	}
+}
+
+// assertNoLogFiles fails if any file, complete or partial, was left in the logs directory
+func assertNoLogFiles(t *testing.T, env *testEnv) {
+	t.Helper()
+
+	entries, _ := os.ReadDir(filepath.Join(env.workDir, "logs"))
+	for _, entry := range entries {
+		t.Errorf("unexpected file left in logs directory: %s", entry.Name())
+	}
+}
+
+func TestAuth(t *testing.T) {
+	t.Run("login, status and logout", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		if res := env.run("auth", "login", "--api-key", fakeapi.FixtureAPIKey); res.exitCode != 0 {
+			t.Fatalf("login: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		config := env.readConfig()
+		for _, want := range []string{"api_key: " + fakeapi.FixtureAPIKey, "account_id: " + fakeapi.FixtureAccountID, "name: single-region"} {
+			if !strings.Contains(config, want) {
+				t.Errorf("config does not contain %q:\n%s", want, config)
+			}
+		}
+
+		res := env.run("auth", "status")
+		if res.exitCode != 0 {
+			t.Fatalf("status: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if !strings.Contains(res.stdout, fakeapi.FixtureAccountID) || strings.Contains(res.stdout, fakeapi.FixtureAPIKey) {
+			t.Errorf("status output:\n%s", res.stdout)
+		}
+
+		if res := env.run("auth", "logout"); res.exitCode != 0 {
+			t.Fatalf("logout: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if config := env.readConfig(); strings.Contains(config, fakeapi.FixtureAPIKey) || strings.Contains(config, fakeapi.FixtureAccountID) {
+			t.Errorf("credentials left after logout:\n%s", config)
+		}
+		if res := env.run("auth", "status"); res.exitCode != 1 {
+			t.Errorf("status after logout: exit code = %d, want 1", res.exitCode)
+		}
+	})
+
+	t.Run("rejected key", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		if res := env.run("auth", "login", "--api-key", "revoked-key"); res.exitCode != 3 {
+			t.Errorf("login: exit code = %d, want 3", res.exitCode)
+		}
+		if _, err := os.Stat(env.configDir()); !os.IsNotExist(err) {
+			t.Errorf("config directory was created")
+		}
+
+		env = newTestEnv(t, "revoked-key")
+		if res := env.run("auth", "status"); res.exitCode != 3 {
+			t.Errorf("status: exit code = %d, want 3", res.exitCode)
+		}
+	})
+
+	t.Run("other account needs --force", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig("api_key: other-key\naccount_id: acc-other\n")
+		if res := env.run("auth", "login", "--api-key", fakeapi.FixtureAPIKey); res.exitCode != 1 {
+			t.Errorf("login: exit code = %d, want 1", res.exitCode)
+		}
+		if res := env.run("auth", "login", "--api-key", fakeapi.FixtureAPIKey, "--force"); res.exitCode != 0 {
+			t.Errorf("login --force: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+	})
+}
+
+func TestDoctor(t *testing.T) {
+	t.Run("healthy", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig(profilesConfig)
+		res := env.run("doctor", "--profile", "staging")
+		if res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+		}
+		if !strings.Contains(res.stdout, "PASS Authentication") {
+			t.Errorf("stdout:\n%s", res.stdout)
+		}
+	})
+
+	t.Run("problems", func(t *testing.T) {
+		env := newTestEnv(t, "revoked-key")
+		configFile := filepath.Join(env.configDir(), "config.yaml")
+		os.Chmod(configFile, 0644)
+
+		res := env.run("doctor")
+		if res.exitCode != 1 {
+			t.Errorf("exit code = %d, want 1", res.exitCode)
+		}
+		for _, want := range []string{"chmod 600 " + configFile, "schema version 1", "the API key was rejected"} {
+			if !strings.Contains(res.stdout, want) {
+				t.Errorf("stdout does not contain %q:\n%s", want, res.stdout)
+			}
+		}
+
+		// The doctor only reads the configuration
+		if backups, _ := filepath.Glob(configFile + ".*.bak"); len(backups) > 0 {
+			t.Errorf("config file was upgraded: %v", backups)
+		}
+	})
+
+	t.Run("invalid YAML", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig("profiles: [\n")
+		res := env.run("doctor")
+		if res.exitCode != 1 || !strings.Contains(res.stdout, "FAIL Config syntax") {
+			t.Errorf("exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+		}
+	})
+}
+
+func TestSignUpNonInteractive(t *testing.T) {
+	signupArgs := []string{"sign-up", "--email", "new@example.com", "--plan", "single-region", "--region", "tokyo.asia", "--targets", "5"}
+
+	t.Run("flags", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		res := env.run(append(signupArgs, "--yes")...)
+		if res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if config := env.readConfig(); !strings.Contains(config, "region: tokyo.asia") || !strings.Contains(config, "number_of_targets: 5") {
+			t.Errorf("account not saved:\n%s", config)
+		}
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		res := env.run(append(signupArgs, "--yes", "--output", "json")...)
+		if res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		var resp models.SignupResponse
+		if err := json.Unmarshal([]byte(res.stdout), &resp); err != nil {
+			t.Fatalf("stdout is not a JSON sign-up response: %v\n%s", err, res.stdout)
+		}
+		if resp.AccountID == "" || !strings.Contains(env.readConfig(), resp.AccountID) {
+			t.Errorf("account %q not saved", resp.AccountID)
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(res.stdout), &fields); err != nil {
+			t.Fatal(err)
+		}
+		keys := slices.Sorted(maps.Keys(fields))
+		if want := []string{"account-id", "api-key", "plan", "stripe-url"}; !slices.Equal(keys, want) {
+			t.Errorf("JSON keys = %v, want %v", keys, want)
+		}
+	})
+
+	t.Run("from file", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		signupFile := filepath.Join(env.workDir, "signup.yaml")
+		os.WriteFile(signupFile, []byte("email: new@example.com\nplan:\n  name: worldwide\n  number_of_targets: 3\n"), 0600)
+		res := env.run("sign-up", "--from-file", signupFile, "--targets", "4", "--yes", "-o", "yaml")
+		if res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if !strings.Contains(res.stdout, "name: worldwide") || !strings.Contains(res.stdout, "number_of_targets: 4") {
+			t.Errorf("stdout:\n%s", res.stdout)
+		}
+	})
+
+	for name, args := range map[string][]string{
+		"missing values":  {"sign-up", "--email", "new@example.com", "--yes"},
+		"invalid region":  {"sign-up", "--email", "new@example.com", "--plan", "single-region", "--region", "atlantis", "--targets", "5", "--yes"},
+		"invalid email":   {"sign-up", "--email", "nobody", "--plan", "worldwide", "--targets", "5", "--yes"},
+		"no confirmation": signupArgs,
+	} {
+		t.Run(name, func(t *testing.T) {
+			env := newTestEnv(t, "")
+			if res := env.run(args...); res.exitCode != 1 {
+				t.Errorf("exit code = %d, want 1, stderr: %s", res.exitCode, res.stderr)
+			}
+			if len(env.api.Requests()) > 0 {
+				t.Errorf("sign-up request sent")
+			}
+		})
+	}
+}
+
+func TestSignUpExistingCredentials(t *testing.T) {
+	signupArgs := []string{"sign-up", "--email", "new@example.com", "--plan", "worldwide", "--targets", "5", "--yes"}
+	config := fmt.Sprintf("version: 2\ncurrent_profile: default\nprofiles:\n  default:\n    api_key: %s\n    account_id: %s\n",
+		fakeapi.FixtureAPIKey, fakeapi.FixtureAccountID)
+
+	t.Run("selected profile", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig(config)
+		res := env.run(append(signupArgs, "--profile", "default")...)
+		if res.exitCode != 1 || !strings.Contains(res.stderr, "--force") {
+			t.Errorf("exit code = %d, want 1, stderr: %s", res.exitCode, res.stderr)
+		}
+		if len(env.api.Requests()) > 0 {
+			t.Errorf("sign-up request sent")
+		}
+		if env.readConfig() != config {
+			t.Errorf("credentials overwritten:\n%s", env.readConfig())
+		}
+	})
+
+	t.Run("new profile", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig(config)
+		if res := env.run(signupArgs...); res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if res := env.run("config", "get", "account_id", "--profile", "default"); strings.TrimSpace(res.stdout) != fakeapi.FixtureAccountID {
+			t.Errorf("default profile account_id = %q", res.stdout)
+		}
+		if !strings.Contains(env.readConfig(), fakeapi.FixtureAPIKey) {
+			t.Errorf("credentials overwritten")
+		}
+	})
+
+	t.Run("force", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig(config)
+		res := env.run(append(signupArgs, "--profile", "default", "--force")...)
+		if res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if strings.Contains(env.readConfig(), fakeapi.FixtureAPIKey) {
+			t.Errorf("credentials not replaced:\n%s", env.readConfig())
+		}
+		backups, _ := filepath.Glob(filepath.Join(env.configDir(), "config.yaml.*.bak"))
+		if len(backups) != 1 {
+			t.Fatalf("backups = %v, want one", backups)
+		}
+		if data, _ := os.ReadFile(backups[0]); !strings.Contains(string(data), fakeapi.FixtureAPIKey) {
+			t.Errorf("backup does not hold the previous credentials:\n%s", data)
+		}
+	})
+
+	t.Run("config out", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.writeConfig(config)
+		configOut := filepath.Join(env.workDir, "new", "config.yaml")
+		if res := env.run(append(signupArgs, "--config-out", configOut)...); res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		data, err := os.ReadFile(configOut)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), "current_profile: default") || !strings.Contains(string(data), "api_key:") {
+			t.Errorf("config-out:\n%s", data)
+		}
+		if env.readConfig() != config {
+			t.Errorf("default config changed:\n%s", env.readConfig())
+		}
+
+		if res := env.run(append(signupArgs, "--config-out", configOut)...); res.exitCode != 1 {
+			t.Errorf("existing config-out: exit code = %d, want 1", res.exitCode)
+		}
+		if res := env.run(append(signupArgs, "--config-out", configOut, "--force")...); res.exitCode != 0 {
+			t.Errorf("existing config-out with --force: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		if backups, _ := filepath.Glob(configOut + ".*.bak"); len(backups) != 1 {
+			t.Errorf("backups = %v, want one", backups)
+		}
+	})
+}
+
+func TestAccountShow(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	res := env.run("account", "show")
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	for _, want := range []string{fakeapi.FixtureAccountID, "ops@example.com", "london.europe (London, United Kingdom)", "3 of 10 used", "2024-09-01"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("output does not contain %q:\n%s", want, res.stdout)
+		}
+	}
+
+	// The plan details cached in the profile are refreshed
+	if config := env.readConfig(); !strings.Contains(config, "number_of_targets: 10") || !strings.Contains(config, "name: single-region") {
+		t.Errorf("profile not refreshed:\n%s", config)
+	}
+
+	res = env.run("account", "show", "-o", "json")
+	var account models.Account
+	if err := json.Unmarshal([]byte(res.stdout), &account); err != nil {
+		t.Fatalf("stdout is not a JSON account: %v\n%s", err, res.stdout)
+	}
+	if account.TargetsUsed != 3 || account.Plan.NumberOfTargets != 10 || account.CreatedAt.IsZero() {
+		t.Errorf("account = %+v", account)
+	}
+
+	// Credentials from the environment leave other accounts' profiles alone
+	env.writeConfig("version: 2\ncurrent_profile: default\nprofiles:\n  default:\n    account_id: acc-other\n")
+	env.env = []string{"GBX_API_KEY=" + fakeapi.FixtureAPIKey}
+	if res := env.run("account", "show"); res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if config := env.readConfig(); strings.Contains(config, "plan:") {
+		t.Errorf("profile of another account refreshed:\n%s", config)
+	}
+}
+
+func TestAccountChangePlan(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	// A cheaper plan is applied at once
+	res := env.run("account", "change-plan", "--targets", "5", "--yes")
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "Targets: 10 → 5") {
+		t.Errorf("stdout does not show the change:\n%s", res.stdout)
+	}
+	if config := env.readConfig(); !strings.Contains(config, "number_of_targets: 5") {
+		t.Errorf("profile not updated:\n%s", config)
+	}
+
+	// A more expensive one waits for its payment
+	res = env.run("account", "change-plan", "--plan", "all-continents", "--yes")
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	for _, want := range []string{"single-region → all-continents", "+ northern-california.americas", "- london.europe", "checkout.stripe.com"} {
+		if !strings.Contains(res.stdout, want) {
+			t.Errorf("stdout does not contain %q:\n%s", want, res.stdout)
+		}
+	}
+	if config := env.readConfig(); strings.Contains(config, "all-continents") {
+		t.Errorf("profile updated before the payment:\n%s", config)
+	}
+
+	env.api.ConfirmPayment(fakeapi.FixtureAccountID)
+	if res := env.run("account", "show"); !strings.Contains(res.stdout, "all-continents") {
+		t.Errorf("account show after the payment:\n%s", res.stdout)
+	}
+	if config := env.readConfig(); !strings.Contains(config, "name: all-continents") || strings.Contains(config, "region:") {
+		t.Errorf("profile not refreshed after the payment:\n%s", config)
+	}
+
+	for name, args := range map[string][]string{
+		"no changes":      {"account", "change-plan", "--yes"},
+		"same plan":       {"account", "change-plan", "--targets", "5", "--yes"},
+		"missing region":  {"account", "change-plan", "--plan", "single-region", "--yes"},
+		"region":          {"account", "change-plan", "--region", "tokyo.asia", "--yes"},
+		"no confirmation": {"account", "change-plan", "--targets", "50"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if res := env.run(args...); res.exitCode != 1 {
+				t.Errorf("exit code = %d, want 1, stderr: %s", res.exitCode, res.stderr)
+			}
+		})
+	}
+}
+
+func TestAccountCancel(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	for name, args := range map[string][]string{
+		"no choice":       {"account", "cancel", "--confirm", fakeapi.FixtureAccountID},
+		"no confirmation": {"account", "cancel", "--at-period-end"},
+		"wrong account":   {"account", "cancel", "--at-period-end", "--confirm", "acc-other"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if res := env.run(args...); res.exitCode != 1 {
+				t.Errorf("exit code = %d, want 1, stderr: %s", res.exitCode, res.stderr)
+			}
+			if slices.Contains(env.api.Requests(), "POST /account/cancel") {
+				t.Errorf("cancellation sent")
+			}
+		})
+	}
+
+	res := env.run("account", "cancel", "--at-period-end", "--confirm", fakeapi.FixtureAccountID)
+	if res.exitCode != 0 || !strings.Contains(res.stdout, "will not renew") {
+		t.Fatalf("exit code = %d, stdout: %s, stderr: %s", res.exitCode, res.stdout, res.stderr)
+	}
+	if res := env.run("account", "show"); !strings.Contains(res.stdout, "canceled at the end of the billing period") {
+		t.Errorf("account show:\n%s", res.stdout)
+	}
+	if res := env.run("account", "cancel", "--at-period-end", "--confirm", fakeapi.FixtureAccountID); res.exitCode != 1 {
+		t.Errorf("canceling twice: exit code = %d, want 1", res.exitCode)
+	}
+
+	res = env.run("account", "cancel", "--immediately", "--confirm", fakeapi.FixtureAccountID)
+	if res.exitCode != 0 || !strings.Contains(res.stdout, "is canceled") {
+		t.Fatalf("exit code = %d, stdout: %s, stderr: %s", res.exitCode, res.stdout, res.stderr)
+	}
+	if res := env.run("account", "show"); !strings.Contains(res.stdout, "Canceled:") {
+		t.Errorf("account show:\n%s", res.stdout)
+	}
+}
+
+func TestAccountExportData(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+	archivePath := filepath.Join(env.workDir, "export.zip")
+
+	res := env.run("account", "export-data", "--file", archivePath)
+	if res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if !strings.Contains(res.stdout, "SHA-256: ") {
+		t.Errorf("stdout:\n%s", res.stdout)
+	}
+
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("archive permissions = %v, want 0600", info.Mode().Perm())
+	}
+
+	archive, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatalf("not a zip archive: %v", err)
+	}
+	defer archive.Close()
+	contents := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(r)
+		r.Close()
+		contents[f.Name] = string(data)
+	}
+	for name, want := range map[string]string{
+		"account.json": fakeapi.FixtureAccountID,
+		"targets.json": fakeapi.FixtureTargetDomain,
+		"logs.json":    fixtureLogName("09"),
+	} {
+		if !strings.Contains(contents[name], want) {
+			t.Errorf("%s does not contain %q:\n%s", name, want, contents[name])
+		}
+	}
+
+	if res := env.run("account", "export-data", "--file", archivePath); res.exitCode != 1 {
+		t.Errorf("existing file: exit code = %d, want 1", res.exitCode)
+	}
+	if res := env.run("account", "export-data", "--file", archivePath, "--force"); res.exitCode != 0 {
+		t.Errorf("existing file with --force: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	// Without --file the archive is named after the account
+	if res := env.run("account", "export-data"); res.exitCode != 0 {
+		t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(env.workDir, "gbx-export-"+fakeapi.FixtureAccountID+"-*.zip")); len(matches) != 1 {
+		t.Errorf("default archive not found: %v", matches)
+	}
+}
+
+func TestRegionValidation(t *testing.T) {
+	env := newTestEnv(t, fakeapi.FixtureAPIKey)
+
+	for _, args := range [][]string{
+		{"logs", "list", "--region", "londn.europe", "--target_domain", fakeapi.FixtureTargetDomain, "--date", fakeapi.FixtureDate},
+		{"logs", "download", "--region", "londn.europe", "--target_domain", fakeapi.FixtureTargetDomain, "--date", fakeapi.FixtureDate, "--fileName", fixtureLogName("03")},
+		{"sign-up", "--email", "new@example.com", "--plan", "single-region", "--region", "londn.europe", "--targets", "5", "--yes"},
+		{"config", "set", "plan.region", "londn.europe"},
+	} {
+		res := env.run(args...)
+		if res.exitCode != 1 || !strings.Contains(res.stderr, `did you mean "london.europe"?`) {
+			t.Errorf("%s %s: exit code = %d, stderr: %s", args[0], args[1], res.exitCode, res.stderr)
+		}
+	}
+	if requests := env.api.Requests(); len(requests) > 0 {
+		t.Errorf("requests sent for an unknown region: %v", requests)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	t.Run("refreshed from the API and cached", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		catalog := *models.DefaultCatalog
+		catalog.Regions = append(slices.Clone(catalog.Regions),
+			models.Region{Code: "lagos.africa", Country: "Nigeria", City: "Lagos", Continent: models.ContinentAfrica})
+		env.api.SetCatalog(&catalog)
+
+		res := env.run("regions", "list", "--continent", "africa", "--output", "json")
+		if res.exitCode != 0 {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		var regions []models.Region
+		if err := json.Unmarshal([]byte(res.stdout), &regions); err != nil {
+			t.Fatalf("stdout is not a JSON region list: %v\n%s", err, res.stdout)
+		}
+		if len(regions) != 2 || regions[1].Code != "lagos.africa" {
+			t.Errorf("regions = %+v", regions)
+		}
+		if _, err := os.Stat(filepath.Join(env.home, ".cache", "gbx", "catalog.json")); err != nil {
+			t.Errorf("catalog not cached: %v", err)
+		}
+
+		// The cached catalog is used offline, for listing and for validation
+		env.api.InjectFault(fakeapi.Fault{Path: "/catalog", Status: 503})
+		if res := env.run("regions", "list", "--refresh", "--max-retries", "0"); res.exitCode != 0 ||
+			!strings.Contains(res.stdout, "lagos.africa") || !strings.Contains(res.stderr, "showing the catalog cached on") {
+			t.Errorf("offline: exit code = %d, stdout:\n%s\nstderr: %s", res.exitCode, res.stdout, res.stderr)
+		}
+		if res := env.run("config", "set", "plan.region", "lagos.africa"); res.exitCode != 0 {
+			t.Errorf("config set: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+	})
+
+	t.Run("built-in catalog", func(t *testing.T) {
+		env := newTestEnv(t, "")
+		env.api.SetCatalog(nil)
+
+		res := env.run("plans", "list")
+		if res.exitCode != 0 || res.stderr != "" {
+			t.Fatalf("exit code = %d, stderr: %s", res.exitCode, res.stderr)
+		}
+		for _, name := range models.PlanNames {
+			if !strings.Contains(res.stdout, name) {
+				t.Errorf("plan %s not listed:\n%s", name, res.stdout)
+			}
+		}
+
+		res = env.run("plans", "compare", "--continent", "middle-east")
+		if res.exitCode != 0 || !strings.Contains(res.stdout, "uae.middle-east") || strings.Contains(res.stdout, "london.europe") {
+			t.Errorf("compare: exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+		}
+
+		if res := env.run("plans", "show", "all-continents"); res.exitCode != 0 || !strings.Contains(res.stdout, "cape-town.africa") {
+			t.Errorf("show: exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+		}
+		if res := env.run("plans", "show", "galactic"); res.exitCode != 1 {
+			t.Errorf("show unknown plan: exit code = %d, want 1", res.exitCode)
+		}
+	})
+}
+
+func TestSignUpResume(t *testing.T) {
+	env := newTestEnv(t, "")
+	signupArgs := []string{"sign-up", "--email", "new@example.com", "--plan", "worldwide", "--targets", "5", "--yes", "--max-retries", "0"}
+	stateFile := filepath.Join(env.configDir(), "signup.json")
+
+	env.api.InjectFault(fakeapi.Fault{Path: "/sign-up", Status: 503, Times: 1})
+	res := env.run(signupArgs...)
+	if res.exitCode != 7 || !strings.Contains(res.stderr, "gbx sign-up --resume") {
+		t.Fatalf("exit code = %d, want 7, stderr: %s", res.exitCode, res.stderr)
+	}
+	if _, err := os.Stat(stateFile); err != nil {
+		t.Fatalf("sign-up state not saved: %v", err)
+	}
+
+	if res := env.run("sign-up", "--resume", "--yes"); res.exitCode != 0 {
+		t.Fatalf("resume: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+
+	var keys []string
+	for _, header := range env.api.RequestHeaders() {
+		if key := header.Get("Idempotency-Key"); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != 2 || keys[0] != keys[1] {
+		t.Errorf("idempotency keys = %v, want the same key twice", keys)
+	}
+}
+
+// TestSignUpTwice checks that the idempotency key of a completed sign-up is
+// not reused, and that replaying it does not save the account twice
+func TestSignUpTwice(t *testing.T) {
+	env := newTestEnv(t, "")
+	signupArgs := []string{"sign-up", "--email", "new@example.com", "--plan", "worldwide", "--targets", "3", "--yes", "-o", "json"}
+
+	var accountIDs, stripeURLs []string
+	for _, args := range [][]string{signupArgs, signupArgs, {"sign-up", "--resume", "--yes", "-o", "json"}} {
+		res := env.run(args...)
+		if res.exitCode != 0 {
+			t.Fatalf("%v: exit code = %d, stderr: %s", args, res.exitCode, res.stderr)
+		}
+		var resp models.SignupResponse
+		if err := json.Unmarshal([]byte(res.stdout), &resp); err != nil {
+			t.Fatalf("stdout is not a JSON sign-up response: %v\n%s", err, res.stdout)
+		}
+		accountIDs = append(accountIDs, resp.AccountID)
+		stripeURLs = append(stripeURLs, resp.StripeURL)
+	}
+
+	if accountIDs[0] == accountIDs[1] {
+		t.Errorf("the second sign-up replayed account %s", accountIDs[0])
+	}
+	if accountIDs[2] != accountIDs[1] {
+		t.Errorf("resume returned account %s, want %s", accountIDs[2], accountIDs[1])
+	}
+
+	var file models.ConfigFile
+	if err := yaml.Unmarshal([]byte(env.readConfig()), &file); err != nil {
+		t.Fatal(err)
+	}
+	profiles := map[string]string{}
+	for name, config := range file.Profiles {
+		if other, exists := profiles[config.AccountID]; exists {
+			t.Errorf("profiles %q and %q both hold account %s", other, name, config.AccountID)
+		}
+		profiles[config.AccountID] = name
+	}
+	if len(file.Profiles) != 2 {
+		t.Errorf("profiles = %d, want 2", len(file.Profiles))
+	}
+
+	// The first account, still unpaid, keeps its payment link
+	res := env.run("account", "status", "--profile", profiles[accountIDs[0]])
+	if res.exitCode != 0 || !strings.Contains(res.stdout, "waiting for the payment") || !strings.Contains(res.stdout, stripeURLs[0]) {
+		t.Errorf("status of the first account: exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+	}
+	env.api.ConfirmPayment(accountIDs[0])
+	if res := env.run("account", "status", "--profile", profiles[accountIDs[0]]); res.exitCode != 0 {
+		t.Fatalf("status after payment: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	if kept, _ := filepath.Glob(filepath.Join(env.configDir(), "signup-*.json")); len(kept) > 0 {
+		t.Errorf("sign-up state kept after the payment: %v", kept)
+	}
+	if _, err := os.Stat(filepath.Join(env.configDir(), "signup.json")); err != nil {
+		t.Errorf("state of the second sign-up removed: %v", err)
+	}
+}
+
+func TestAccountStatusWait(t *testing.T) {
+	env := newTestEnv(t, "")
+	res := env.run("sign-up", "--email", "new@example.com", "--plan", "single-region", "--region", "tokyo.asia", "--targets", "5", "--yes", "-o", "json")
+	if res.exitCode != 0 {
+		t.Fatalf("sign-up: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	var resp models.SignupResponse
+	if err := json.Unmarshal([]byte(res.stdout), &resp); err != nil {
+		t.Fatal(err)
+	}
+
+	res = env.run("account", "status")
+	if res.exitCode != 0 || !strings.Contains(res.stdout, "waiting for the payment") || !strings.Contains(res.stdout, resp.StripeURL) {
+		t.Errorf("status: exit code = %d, stdout:\n%s", res.exitCode, res.stdout)
+	}
+
+	time.AfterFunc(300*time.Millisecond, func() { env.api.ConfirmPayment(resp.AccountID) })
+	res = env.run("account", "status", "--wait", "--interval", "100ms", "--timeout", "10s")
+	if res.exitCode != 0 {
+		t.Fatalf("status --wait: exit code = %d, stderr: %s", res.exitCode, res.stderr)
+	}
+	trialEnd := time.Now().Add(7 * 24 * time.Hour).Format(time.DateOnly)
+	if !strings.Contains(res.stdout, "active, in free trial") || !strings.Contains(res.stdout, trialEnd) {
+		t.Errorf("status --wait stdout:\n%s", res.stdout)
+	}
+	if _, err := os.Stat(filepath.Join(env.configDir(), "signup.json")); !os.IsNotExist(err) {
+		t.Errorf("sign-up state kept after the account became active")
+	}
+}
@@ -0,0 +1,660 @@
+// Package fakeapi implements an in-memory fake of the Global Blackbox API for
+// hermetic tests. It serves /catalog, /sign-up, /account, /account/plan,
+// /account/cancel, /account/export, /logs and /logs/{file} with realistic
+// fixtures and can inject failures and latency.
+//
+// Use it from Go tests with httptest:
+//
+//	srv := httptest.NewServer(fakeapi.New())
+//	defer srv.Close()
+//
+// or run it standalone with the hidden "gbx dev fake-api" command.
+package fakeapi
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"globalblackbox.io/gbx/models"
+)
+
+// Fixture credentials and log files served by a new Server
+const (
+	FixtureAPIKey       = "gbx-test-key"
+	FixtureAccountID    = "acc-test-0001"
+	FixtureRegion       = "london.europe"
+	FixtureTargetDomain = "example.com"
+	FixtureDate         = "2024-10-01"
+)
+
+// Fault makes the server fail matching requests instead of serving them
+type Fault struct {
+	// Path restricts the fault to request paths with this prefix ("" matches all)
+	Path string
+
+	// Status is the HTTP status returned, e.g. 401, 429 or 500. When zero the
+	// request is served normally after Delay.
+	Status int
+
+	// Times is the number of requests the fault applies to (0 means forever)
+	Times int
+
+	// RetryAfter is sent as the Retry-After header when non-empty
+	RetryAfter string
+
+	// Delay is added before responding, to simulate slow responses
+	Delay time.Duration
+}
+
+// account is a registered account and its plan
+type account struct {
+	id          string
+	email       string
+	plan        models.SignupPlan
+	status      string
+	trialEndsAt *time.Time
+	periodEnd   *time.Time
+	targets     []string
+	createdAt   time.Time
+
+	cancelAtPeriodEnd bool
+	canceledAt        *time.Time
+
+	// pendingPlan is a plan change waiting for its payment
+	pendingPlan *models.SignupPlan
+}
+
+// signup is a sign-up answered for an idempotency key
+type signup struct {
+	req  models.SignupRequest
+	resp models.SignupResponse
+}
+
+// planChange is a plan change answered for an idempotency key
+type planChange struct {
+	req  models.PlanChangeRequest
+	resp models.PlanChangeResponse
+}
+
+// trialDays is the length of the single-region free trial
+const trialDays = 7
+
+// logKey identifies the log files of a region, target domain and date
+type logKey struct {
+	region       string
+	targetDomain string
+	date         string
+}
+
+// Server is a fake Global Blackbox API. Create one with New.
+type Server struct {
+	mu       sync.Mutex
+	mux      *http.ServeMux
+	accounts map[string]*account // by API key
+	logs     map[logKey]map[string]string
+	catalog  *models.Catalog
+	signups  map[string]*signup     // by idempotency key
+	changes  map[string]*planChange // by idempotency key
+	faults   []*Fault
+	latency  time.Duration
+	requests []string
+	headers  []http.Header
+
+	clientStatus  string
+	clientMessage string
+}
+
+// New creates a Server with an active fixture account (FixtureAPIKey) and a day of
+// probe failure logs for FixtureRegion, FixtureTargetDomain and FixtureDate
+func New() *Server {
+	s := &Server{
+		mux:      http.NewServeMux(),
+		accounts: map[string]*account{},
+		logs:     map[logKey]map[string]string{},
+		catalog:  models.DefaultCatalog,
+		signups:  map[string]*signup{},
+		changes:  map[string]*planChange{},
+	}
+
+	createdAt := time.Date(2024, time.September, 1, 9, 30, 0, 0, time.UTC)
+	periodEnd := createdAt
+	for !periodEnd.After(time.Now()) {
+		periodEnd = periodEnd.AddDate(0, 1, 0)
+	}
+	s.accounts[FixtureAPIKey] = &account{
+		id:        FixtureAccountID,
+		email:     "ops@example.com",
+		plan:      models.SignupPlan{Name: "single-region", Region: FixtureRegion, NumberOfTargets: 10},
+		status:    models.AccountActive,
+		periodEnd: &periodEnd,
+		targets:   []string{FixtureTargetDomain, "api." + FixtureTargetDomain, "status." + FixtureTargetDomain},
+		createdAt: createdAt,
+	}
+	for _, hour := range []string{"03", "09", "17"} {
+		name := fmt.Sprintf("probe-failures-%sT%s-00-00Z.log", FixtureDate, hour)
+		s.AddLog(FixtureRegion, FixtureTargetDomain, FixtureDate, name, fixtureLog(FixtureDate, hour))
+	}
+
+	s.mux.HandleFunc("GET /catalog", s.handleGetCatalog)
+	s.mux.HandleFunc("POST /sign-up", s.handleSignup)
+	s.mux.HandleFunc("GET /logs", s.authenticated(s.handleListLogs))
+	s.mux.HandleFunc("GET /logs/{file}", s.authenticated(s.handleDownloadLog))
+	s.mux.HandleFunc("GET /account", s.authenticated(s.handleGetAccount))
+	s.mux.HandleFunc("POST /account/plan", s.authenticated(s.handleChangePlan))
+	s.mux.HandleFunc("POST /account/cancel", s.authenticated(s.handleCancel))
+	s.mux.HandleFunc("GET /account/export", s.authenticated(s.handleExport))
+	return s
+}
+
+// fixtureLog renders a few probe failure lines in the format of the real logs
+func fixtureLog(date, hour string) string {
+	var b strings.Builder
+	for minute := 0; minute < 3; minute++ {
+		fmt.Fprintf(&b, "%sT%s:%02d:00Z probe=http_2xx target=https://%s region=%s success=0 status_code=503 duration_seconds=1.204\n",
+			date, hour, minute*7, FixtureTargetDomain, FixtureRegion)
+	}
+	return b.String()
+}
+
+// AddLog adds a log file with the given content
+func (s *Server) AddLog(region, targetDomain, date, name, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := logKey{region, targetDomain, date}
+	if s.logs[key] == nil {
+		s.logs[key] = map[string]string{}
+	}
+	s.logs[key][name] = content
+}
+
+// InjectFault adds a fault. Faults are matched in the order they were added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// SetLatency delays every response by d, faults included
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetClientStatus makes every response report the client version as
+// "deprecated" or "unsupported" through the X-Gbx-Client-Status header.
+// An empty status stops reporting it.
+func (s *Server) SetClientStatus(status, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientStatus, s.clientMessage = status, message
+}
+
+// RequestHeaders returns the headers of every request received so far
+func (s *Server) RequestHeaders() []http.Header {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]http.Header(nil), s.headers...)
+}
+
+// Requests returns the "METHOD /path" of every request received so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	s.headers = append(s.headers, r.Header.Clone())
+	fault := s.takeFault(r.URL.Path)
+	latency := s.latency
+	if s.clientStatus != "" {
+		w.Header().Set("X-Gbx-Client-Status", s.clientStatus)
+		w.Header().Set("X-Gbx-Client-Message", s.clientMessage)
+	}
+	s.mu.Unlock()
+
+	if !sleep(r, latency) {
+		return
+	}
+	if fault != nil {
+		if !sleep(r, fault.Delay) {
+			return
+		}
+		if fault.Status != 0 {
+			if fault.RetryAfter != "" {
+				w.Header().Set("Retry-After", fault.RetryAfter)
+			}
+			writeError(w, fault.Status, "injected_fault", http.StatusText(fault.Status))
+			return
+		}
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// sleep waits for d, or reports false when the request is canceled first
+func sleep(r *http.Request, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// takeFault returns the first fault matching path and uses up one of its
+// occurrences. The caller must hold s.mu.
+func (s *Server) takeFault(path string) *Fault {
+	for i, f := range s.faults {
+		if !strings.HasPrefix(path, f.Path) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// authenticated rejects requests without a known x-api-key, like the API gateway does
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		_, ok := s.account(r)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"Forbidden"}`))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// SetCatalog replaces the plans and regions served by /catalog. A nil catalog
+// makes /catalog answer 404, like an API without the endpoint.
+func (s *Server) SetCatalog(catalog *models.Catalog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.catalog = catalog
+}
+
+// ConfirmPayment marks the payment of a new account or of a plan change as
+// received, as the Stripe webhook does. A pending plan change is applied.
+// Otherwise single-region accounts start their free trial and others become
+// active. It reports whether the account exists.
+func (s *Server) ConfirmPayment(accountID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, acct := range s.accounts {
+		if acct.id != accountID {
+			continue
+		}
+		if acct.pendingPlan != nil {
+			acct.plan, acct.pendingPlan = *acct.pendingPlan, nil
+			return true
+		}
+		periodEnd := time.Now().UTC().AddDate(0, 1, 0).Truncate(time.Second)
+		acct.status, acct.periodEnd = models.AccountActive, &periodEnd
+		if acct.plan.Name == "single-region" {
+			trialEndsAt := time.Now().UTC().Add(trialDays * 24 * time.Hour).Truncate(time.Second)
+			acct.status, acct.trialEndsAt, acct.periodEnd = models.AccountTrialing, &trialEndsAt, &trialEndsAt
+		}
+		return true
+	}
+	return false
+}
+
+// account returns the account of the request's API key
+func (s *Server) account(r *http.Request) (*account, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acct, ok := s.accounts[r.Header.Get("x-api-key")]
+	return acct, ok
+}
+
+func (s *Server) handleGetCatalog(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	catalog := s.catalog
+	s.mu.Unlock()
+
+	if catalog == nil {
+		writeError(w, http.StatusNotFound, "not_found", "no route for /catalog")
+		return
+	}
+	writeJSON(w, catalog)
+}
+
+func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request) {
+	acct, _ := s.account(r)
+
+	s.mu.Lock()
+	resp := s.accountResponse(acct)
+	s.mu.Unlock()
+
+	writeJSON(w, resp)
+}
+
+// accountResponse describes an account as the API does. The caller holds s.mu.
+func (s *Server) accountResponse(acct *account) models.Account {
+	resp := models.Account{
+		AccountID:        acct.id,
+		Email:            acct.email,
+		Plan:             acct.plan,
+		Status:           acct.status,
+		TargetsUsed:      len(acct.targets),
+		TrialEndsAt:      acct.trialEndsAt,
+		CurrentPeriodEnd: acct.periodEnd,
+		CreatedAt:        acct.createdAt,
+		PendingPlan:      acct.pendingPlan,
+
+		CancelAtPeriodEnd: acct.cancelAtPeriodEnd,
+		CanceledAt:        acct.canceledAt,
+	}
+
+	catalog := s.catalog
+	if catalog == nil {
+		catalog = models.DefaultCatalog
+	}
+	if plan, ok := catalog.LookupPlan(acct.plan.Name); ok && !plan.SingleRegion {
+		for _, region := range catalog.PlanRegions(plan) {
+			resp.Regions = append(resp.Regions, region.Code)
+		}
+	} else if acct.plan.Region != "" {
+		resp.Regions = []string{acct.plan.Region}
+	}
+	return resp
+}
+
+func (s *Server) handleSignup(w http.ResponseWriter, r *http.Request) {
+	var req models.SignupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "request body is not valid JSON")
+		return
+	}
+
+	if !strings.Contains(req.Email, "@") {
+		writeError(w, http.StatusBadRequest, "invalid_email", "invalid email address")
+		return
+	}
+	if code, message := invalidPlan(req.Plan); code != "" {
+		writeError(w, http.StatusBadRequest, code, message)
+		return
+	}
+
+	// A repeated request answers with the account created the first time
+	key := r.Header.Get("Idempotency-Key")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, exists := s.signups[key]; key != "" && exists {
+		if previous.req != req {
+			writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused", "the idempotency key was used for a different sign-up")
+			return
+		}
+		writeJSON(w, previous.resp)
+		return
+	}
+
+	acct := &account{
+		id:        "acc-" + randomHex(6),
+		email:     req.Email,
+		plan:      req.Plan,
+		status:    models.AccountPendingPayment,
+		createdAt: time.Now().UTC().Truncate(time.Second),
+	}
+	apiKey := "gbx-" + randomHex(16)
+	s.accounts[apiKey] = acct
+
+	resp := models.SignupResponse{
+		APIKey:          apiKey,
+		StripeURL:       "https://checkout.stripe.com/c/pay/cs_test_" + randomHex(12),
+		AccountID:       acct.id,
+		Plan:            acct.plan,
+		NumberOfTargets: acct.plan.NumberOfTargets,
+	}
+	if key != "" {
+		s.signups[key] = &signup{req: req, resp: resp}
+	}
+	writeJSON(w, resp)
+}
+
+// planRanks orders the plans by price
+var planRanks = map[string]int{"single-region": 0, "all-continents": 1, "worldwide": 2}
+
+// invalidPlan checks a plan like the API does and returns the error code and
+// message, or empty strings when it is valid
+func invalidPlan(plan models.SignupPlan) (string, string) {
+	switch _, known := planRanks[plan.Name]; {
+	case !known:
+		return "invalid_plan", fmt.Sprintf("unknown plan %q", plan.Name)
+	case plan.Name == "single-region" && plan.Region == "":
+		return "invalid_region", "the single-region plan requires a region"
+	case plan.Name != "single-region" && plan.Region != "":
+		return "invalid_region", fmt.Sprintf("the %s plan has no region to choose", plan.Name)
+	case plan.NumberOfTargets <= 0:
+		return "invalid_targets", "number_of_targets must be positive"
+	}
+	return "", ""
+}
+
+// handleChangePlan applies a cheaper plan at once and keeps a more expensive
+// one pending until it is paid, see ConfirmPayment
+func (s *Server) handleChangePlan(w http.ResponseWriter, r *http.Request) {
+	acct, _ := s.account(r)
+
+	var req models.PlanChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "request body is not valid JSON")
+		return
+	}
+	if code, message := invalidPlan(req.Plan); code != "" {
+		writeError(w, http.StatusBadRequest, code, message)
+		return
+	}
+
+	// A repeated request answers with the outcome of the first one
+	key := r.Header.Get("Idempotency-Key")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if previous, exists := s.changes[key]; key != "" && exists {
+		if previous.req != req {
+			writeError(w, http.StatusUnprocessableEntity, "idempotency_key_reused", "the idempotency key was used for a different plan change")
+			return
+		}
+		writeJSON(w, previous.resp)
+		return
+	}
+
+	switch {
+	case acct.status == models.AccountCanceled:
+		writeError(w, http.StatusConflict, "account_canceled", "the account is canceled")
+		return
+	case req.Plan == acct.plan:
+		writeError(w, http.StatusBadRequest, "plan_unchanged", "the account already has this plan")
+		return
+	}
+
+	resp := models.PlanChangeResponse{Status: models.PlanChangeApplied}
+	if planRanks[req.Plan.Name] > planRanks[acct.plan.Name] || req.Plan.NumberOfTargets > acct.plan.NumberOfTargets {
+		pending := req.Plan
+		acct.pendingPlan = &pending
+		resp.Status = models.PlanChangePendingPayment
+		resp.StripeURL = "https://checkout.stripe.com/c/pay/cs_test_" + randomHex(12)
+	} else {
+		acct.plan, acct.pendingPlan = req.Plan, nil
+	}
+	resp.Account = s.accountResponse(acct)
+
+	if key != "" {
+		s.changes[key] = &planChange{req: req, resp: resp}
+	}
+	writeJSON(w, resp)
+}
+
+// handleCancel ends the subscription at once or schedules its end with the
+// current billing period. Canceling again answers with the account.
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	acct, _ := s.account(r)
+
+	var req models.CancelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", "request body is not valid JSON")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case acct.status == models.AccountCanceled:
+	case req.AtPeriodEnd && acct.periodEnd != nil:
+		acct.cancelAtPeriodEnd = true
+	default:
+		canceledAt := time.Now().UTC().Truncate(time.Second)
+		acct.status, acct.canceledAt = models.AccountCanceled, &canceledAt
+		acct.cancelAtPeriodEnd, acct.periodEnd, acct.pendingPlan = false, nil, nil
+	}
+	writeJSON(w, s.accountResponse(acct))
+}
+
+// handleExport serves the data export of an account: a zip archive of the
+// account, its targets and the index of their log files
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	acct, _ := s.account(r)
+
+	s.mu.Lock()
+	account := s.accountResponse(acct)
+	targets := append([]string{}, acct.targets...)
+	logIndex := []exportedLog{}
+	for key, files := range s.logs {
+		if !slices.Contains(targets, key.targetDomain) {
+			continue
+		}
+		for name := range files {
+			logIndex = append(logIndex, exportedLog{File: name, Region: key.region, TargetDomain: key.targetDomain, Date: key.date})
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(logIndex, func(i, j int) bool { return logIndex[i].File < logIndex[j].File })
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=gbx-export-%s.zip", acct.id))
+	archive := zip.NewWriter(w)
+	for _, entry := range []struct {
+		name string
+		v    interface{}
+	}{
+		{"account.json", account},
+		{"targets.json", targets},
+		{"logs.json", logIndex},
+	} {
+		f, err := archive.Create(entry.name)
+		if err != nil {
+			return
+		}
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(entry.v)
+	}
+	archive.Close()
+}
+
+// exportedLog is an entry of the log index of a data export
+type exportedLog struct {
+	File         string `json:"file"`
+	Region       string `json:"region"`
+	TargetDomain string `json:"target_domain"`
+	Date         string `json:"date"`
+}
+
+func (s *Server) handleListLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := logKey{query.Get("region"), query.Get("target_domain"), query.Get("date")}
+	if key.region == "" || key.targetDomain == "" || key.date == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request", "region, target_domain and date are required")
+		return
+	}
+
+	limit := 10
+	if l := query.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > 50 {
+			writeError(w, http.StatusBadRequest, "invalid_request", "limit must be between 1 and 50")
+			return
+		}
+		limit = n
+	}
+
+	s.mu.Lock()
+	files := []string{}
+	for name := range s.logs[key] {
+		files = append(files, name)
+	}
+	s.mu.Unlock()
+
+	sort.Strings(files)
+	if len(files) > limit {
+		files = files[:limit]
+	}
+	writeJSON(w, models.LogsResponse{LogFiles: files})
+}
+
+func (s *Server) handleDownloadLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key := logKey{query.Get("region"), query.Get("target_domain"), query.Get("date")}
+
+	s.mu.Lock()
+	content, ok := s.logs[key][r.PathValue("file")]
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found", "log file not found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(content))
+}
+
+// writeJSON writes v as a 200 JSON response
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response in the format of the API
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", "req-"+randomHex(8))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"code": code, "message": message})
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
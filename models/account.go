@@ -0,0 +1,76 @@
+package models
+
+import "time"
+
+// Account statuses reported by the API
+const (
+	AccountPendingPayment = "pending_payment"
+	AccountTrialing       = "trialing"
+	AccountActive         = "active"
+	AccountPastDue        = "past_due"
+	AccountCanceled       = "canceled"
+)
+
+// Account is a Global Blackbox account as returned by the API
+type Account struct {
+	AccountID string     `json:"account-id" yaml:"account_id"`
+	Email     string     `json:"email" yaml:"email"`
+	Plan      SignupPlan `json:"plan" yaml:"plan"`
+	Status    string     `json:"status" yaml:"status"`
+
+	// Regions are the codes of the regions the plan probes from
+	Regions []string `json:"regions,omitempty" yaml:"regions,omitempty"`
+
+	// TargetsUsed is the number of probe targets configured, out of
+	// Plan.NumberOfTargets allowed
+	TargetsUsed int `json:"targets-used" yaml:"targets_used"`
+
+	// TrialEndsAt is the end of the free trial while the status is trialing
+	TrialEndsAt *time.Time `json:"trial-ends-at,omitempty" yaml:"trial_ends_at,omitempty"`
+
+	// CurrentPeriodEnd is when the current billing period ends and the
+	// subscription renews
+	CurrentPeriodEnd *time.Time `json:"current-period-end,omitempty" yaml:"current_period_end,omitempty"`
+
+	CreatedAt time.Time `json:"created-at" yaml:"created_at"`
+
+	// CancelAtPeriodEnd is set when the subscription ends with the current
+	// billing period instead of renewing
+	CancelAtPeriodEnd bool `json:"cancel-at-period-end,omitempty" yaml:"cancel_at_period_end,omitempty"`
+
+	// CanceledAt is when an account was canceled
+	CanceledAt *time.Time `json:"canceled-at,omitempty" yaml:"canceled_at,omitempty"`
+
+	// PendingPlan is a plan change waiting for its payment
+	PendingPlan *SignupPlan `json:"pending-plan,omitempty" yaml:"pending_plan,omitempty"`
+}
+
+// Usable reports whether the account is paid for or in its free trial
+func (a *Account) Usable() bool {
+	return a.Status == AccountActive || a.Status == AccountTrialing
+}
+
+// Plan change statuses reported by the API
+const (
+	PlanChangeApplied        = "applied"
+	PlanChangePendingPayment = "pending_payment"
+)
+
+// PlanChangeRequest moves an account to another plan, region or number of targets
+type PlanChangeRequest struct {
+	Plan SignupPlan `json:"plan" yaml:"plan"`
+}
+
+// PlanChangeResponse is the outcome of a plan change. A change that costs
+// more is pending until it is paid through StripeURL.
+type PlanChangeResponse struct {
+	Status    string  `json:"status" yaml:"status"`
+	StripeURL string  `json:"stripe-url,omitempty" yaml:"stripe_url,omitempty"`
+	Account   Account `json:"account" yaml:"account"`
+}
+
+// CancelRequest cancels the subscription of an account, at once or at the
+// end of the current billing period
+type CancelRequest struct {
+	AtPeriodEnd bool `json:"at-period-end" yaml:"at_period_end"`
+}
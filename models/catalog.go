@@ -0,0 +1,135 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Catalog is the set of subscription plans and probe regions on offer
+type Catalog struct {
+	Plans   []Plan   `json:"plans" yaml:"plans"`
+	Regions []Region `json:"regions" yaml:"regions"`
+}
+
+// DefaultCatalog is the catalog built into gbx, used when the API does not
+// provide one
+var DefaultCatalog = &Catalog{Plans: Plans, Regions: Regions}
+
+// LookupPlan returns the plan with the given name
+func (c *Catalog) LookupPlan(name string) (Plan, bool) {
+	for _, plan := range c.Plans {
+		if plan.Name == name {
+			return plan, true
+		}
+	}
+	return Plan{}, false
+}
+
+// LookupRegion returns the region with the given code
+func (c *Catalog) LookupRegion(code string) (Region, bool) {
+	for _, region := range c.Regions {
+		if region.Code == code {
+			return region, true
+		}
+	}
+	return Region{}, false
+}
+
+// PlanNames lists the names of the plans
+func (c *Catalog) PlanNames() []string {
+	names := make([]string, len(c.Plans))
+	for i, plan := range c.Plans {
+		names[i] = plan.Name
+	}
+	return names
+}
+
+// PlanRegions returns the regions a plan probes from, or for a single-region
+// plan the regions to choose from
+func (c *Catalog) PlanRegions(plan Plan) []Region {
+	if len(plan.RegionCodes) == 0 {
+		return c.Regions
+	}
+
+	regions := make([]Region, 0, len(plan.RegionCodes))
+	for _, code := range plan.RegionCodes {
+		if region, ok := c.LookupRegion(code); ok {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// SuggestRegions returns the codes of the regions closest to an unknown
+// region code, for "did you mean" hints. Typos in the code and the bare
+// name of a city or country, e.g. "londn.europe" or "japan", are matched.
+func (c *Catalog) SuggestRegions(input string) []string {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return nil
+	}
+	name, _, _ := strings.Cut(input, ".")
+
+	best := len(input)/3 + 1
+	var suggestions []string
+	for _, region := range c.Regions {
+		regionName, _, _ := strings.Cut(region.Code, ".")
+		distance := min(
+			levenshtein(input, region.Code),
+			levenshtein(name, regionName),
+			levenshtein(name, strings.ToLower(strings.ReplaceAll(region.City, " ", "-"))),
+			levenshtein(name, strings.ToLower(strings.ReplaceAll(region.Country, " ", "-"))),
+		)
+		switch {
+		case distance < best:
+			best, suggestions = distance, []string{region.Code}
+		case distance == best:
+			suggestions = append(suggestions, region.Code)
+		}
+	}
+	return suggestions
+}
+
+// PlanDetails describes a plan and its regions for the sign-up prompts
+func (c *Catalog) PlanDetails(plan Plan) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s plan:\n\n", plan.Name)
+	fmt.Fprintf(&b, "Description: %s\n", plan.Description)
+	fmt.Fprintf(&b, "Ideal For: %s\n", plan.IdealFor)
+	if plan.TrialDays > 0 {
+		fmt.Fprintf(&b, "%d-Day Free Trial: This plan includes a %d-day free trial period.\n", plan.TrialDays, plan.TrialDays)
+	}
+	if plan.Example != "" {
+		fmt.Fprintf(&b, "\nExample Usage:\n%s\n", plan.Example)
+	}
+
+	switch {
+	case plan.SingleRegion:
+		b.WriteString("\nAvailable regions:\n")
+	case len(plan.RegionCodes) > 0:
+		b.WriteString("\nIncluded Regions:\n")
+	default:
+		b.WriteString("\nIncluded Regions: all available regions.\n")
+	}
+	if plan.SingleRegion || len(plan.RegionCodes) > 0 {
+		regions := c.PlanRegions(plan)
+		for _, continent := range Continents {
+			var lines []string
+			for _, region := range regions {
+				if region.Continent == continent {
+					lines = append(lines, fmt.Sprintf("- %s, %s (%s)", region.City, region.Country, region.Code))
+				}
+			}
+			if len(lines) > 0 {
+				fmt.Fprintf(&b, "%s:\n%s\n", continent, strings.Join(lines, "\n"))
+			}
+		}
+	}
+
+	if plan.SingleRegion {
+		b.WriteString("\nNumber of Targets: Select the number of targets you wish to monitor in this region.")
+	} else {
+		b.WriteString("\nNumber of Targets: Select the number of targets you wish to monitor across these regions.")
+	}
+	return b.String()
+}
@@ -0,0 +1,22 @@
+package models
+
+// LogsQuery selects the log files to list for a region, target domain and date
+type LogsQuery struct {
+	Region       string
+	TargetDomain string
+	Date         string
+	Limit        int
+}
+
+// LogsResponse is the list of log files returned by the API
+type LogsResponse struct {
+	LogFiles []string `json:"logs"`
+}
+
+// LogFile identifies a single log file to download
+type LogFile struct {
+	FileName     string
+	Region       string
+	TargetDomain string
+	Date         string
+}
@@ -1,27 +1,60 @@
 package models
 
+import "time"
+
 type SignupPlan struct {
 	Name            string `json:"name" yaml:"name"`
 	Region          string `json:"region,omitempty" yaml:"region,omitempty"`
-	NumberOfTargets int    `json:"number_of_targets"`
+	NumberOfTargets int    `json:"number_of_targets" yaml:"number_of_targets,omitempty"`
 }
 
 type SignupRequest struct {
-	Email string     `json:"email"`
-	Plan  SignupPlan `json:"plan"`
+	Email string     `json:"email" yaml:"email"`
+	Plan  SignupPlan `json:"plan" yaml:"plan"`
 }
 
 type SignupResponse struct {
-	APIKey          string     `json:"api-key"`
-	StripeURL       string     `json:"stripe-url"`
-	AccountID       string     `json:"account-id"`
-	Plan            SignupPlan `json:"plan"`
-	NumberOfTargets int        `yaml:"number_of_targets"`
+	APIKey          string     `json:"api-key" yaml:"api_key"`
+	StripeURL       string     `json:"stripe-url" yaml:"stripe_url"`
+	AccountID       string     `json:"account-id" yaml:"account_id"`
+	Plan            SignupPlan `json:"plan" yaml:"plan"`
+	NumberOfTargets int        `json:"-" yaml:"number_of_targets"`
+}
+
+// ConfigFile is the content of the configuration file: its schema version,
+// named profiles, each holding one account and its settings, and the profile
+// used by default
+type ConfigFile struct {
+	Version        int                `yaml:"version"`
+	CurrentProfile string             `yaml:"current_profile,omitempty"`
+	Profiles       map[string]*Config `yaml:"profiles,omitempty"`
 }
 
+// Config is a profile of the configuration file
 type Config struct {
-	APIKey          string     `yaml:"api_key"`
-	AccountID       string     `yaml:"account_id"`
-	Plan            SignupPlan `yaml:"plan"`
-	NumberOfTargets int        `yaml:"number_of_targets"`
+	APIKey          string     `yaml:"api_key,omitempty"`
+	AccountID       string     `yaml:"account_id,omitempty"`
+	Plan            SignupPlan `yaml:"plan,omitempty"`
+	NumberOfTargets int        `yaml:"number_of_targets,omitempty"`
+
+	// CredentialStore is "encrypted" to keep the API key in a passphrase
+	// protected credentials.enc file instead of api_key
+	CredentialStore string `yaml:"credential_store,omitempty"`
+
+	// CredentialHelper is an external program keeping the API key, e.g. pass-gbx
+	CredentialHelper string `yaml:"credential_helper,omitempty"`
+
+	// APIURL overrides the API endpoint, see also --api-url and GBX_API_URL
+	APIURL string `yaml:"api_url,omitempty"`
+
+	// HTTP retry settings, overridden by the --max-retries and --retry-timeout flags
+	MaxRetries   *int          `yaml:"max_retries,omitempty"`
+	RetryTimeout time.Duration `yaml:"retry_timeout,omitempty"`
+
+	// Proxy and TLS settings, overridden by the matching global flags
+	ProxyURL      string   `yaml:"proxy_url,omitempty"`
+	CABundles     []string `yaml:"ca_bundles,omitempty"`
+	ClientCert    string   `yaml:"client_cert,omitempty"`
+	ClientKey     string   `yaml:"client_key,omitempty"`
+	TLSMinVersion string   `yaml:"tls_min_version,omitempty"`
 }
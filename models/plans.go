@@ -1,82 +1,70 @@
 package models
 
-// PlanDetails maps plan names to their detailed descriptions
-var PlanDetails = map[string]string{
-	"single-region": `single-region plan:
-
-	Description: Access to one probe per minute per target in a single region of your choice.
-	Ideal For: Monitoring services critical in a specific geographic location.
-	7-Day Free Trial: This plan includes a 7-day free trial period.
+// Plan is a subscription plan
+type Plan struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	IdealFor    string `json:"ideal_for" yaml:"ideal_for"`
 
-Example Usage:
-- Monitoring up to 10 targets primarily used by customers in São Paulo, Brazil.
-- Selecting the sao-paulo.americas region during sign-up.
+	// RegionCodes are the regions the plan probes from, all of them when empty
+	RegionCodes []string `json:"regions,omitempty" yaml:"regions,omitempty"`
 
-Available regions:
-Americas:
-- Brazil (sao-paulo.americas)
-- Canada (canada.americas)
-- Canada (calgary.americas)
-- United States (northern-virginia.americas)
-- United States (ohio.americas)
-- United States (northern-california.americas)
-- United States (oregon.americas)
-Africa:
-- South Africa (cape-town.africa)
-Asia:
-- Japan (tokyo.asia)
-- Japan (osaka.asia)
-- Hong Kong (hong-kong.asia)
-- India (hyderabad.asia)
-- India (mumbai.asia)
-- Indonesia (jakarta.asia)
-- Malaysia (malaysia.asia)
-- South Korea (seoul.asia)
-- Singapore (singapore.asia)
-Oceania:
-- Australia (melbourne.oceania)
-- Australia (sydney.oceania)
-Europe:
-- United Kingdom (london.europe)
-- Germany (frankfurt.europe)
-- Ireland (ireland.europe)
-- Italy (milan.europe)
-- France (paris.europe)
-- Spain (spain.europe)
-- Sweden (stockholm.europe)
-- Switzerland (zurich.europe)
-Middle east:
-- Israel (tel-aviv.middle-east)
-- Bahrain (bahrain.middle-east)
-- United Arab Emirates (uae.middle-east)
-`,
+	// SingleRegion plans probe from one region, chosen at sign-up
+	SingleRegion bool `json:"single_region" yaml:"single_region"`
 
-	"all-continents": `all-continents plan:
+	// TrialDays is the length of the free trial, if any
+	TrialDays int `json:"trial_days,omitempty" yaml:"trial_days,omitempty"`
 
-Description: Access to one strategically selected region on each continent.
-Ideal For: Ensuring global availability and performance across major continents.
+	Example string `json:"example,omitempty" yaml:"example,omitempty"`
+}
 
-Included Regions:
-  - Americas:
-      - northern-california.americas
-      - sao-paulo.americas
-  - Africa:
-      - cape-town.africa
-  - Asia:
-      - singapore.asia
-  - Oceania:
-      - melbourne.oceania
-  - Europe:
-      - paris.europe
-  - Middle East:
-      - uae.middle-east
+// Plans lists the available subscription plans
+var Plans = []Plan{
+	{
+		Name:         "single-region",
+		Description:  "Access to one probe per minute per target in a single region of your choice.",
+		IdealFor:     "Monitoring services critical in a specific geographic location.",
+		SingleRegion: true,
+		TrialDays:    7,
+		Example: `- Monitoring up to 10 targets primarily used by customers in São Paulo, Brazil.
+- Selecting the sao-paulo.americas region during sign-up.`,
+	},
+	{
+		Name:        "all-continents",
+		Description: "Access to one strategically selected region on each continent.",
+		IdealFor:    "Ensuring global availability and performance across major continents.",
+		RegionCodes: []string{
+			"northern-california.americas",
+			"sao-paulo.americas",
+			"cape-town.africa",
+			"singapore.asia",
+			"melbourne.oceania",
+			"paris.europe",
+			"uae.middle-east",
+		},
+	},
+	{
+		Name:        "worldwide",
+		Description: "Full access to all available regions across the globe.",
+		IdealFor:    "Comprehensive monitoring for services with a worldwide user base.",
+	},
+}
 
-Number of Targets: Select the number of targets you wish to monitor across these regions.`,
+// PlanNames lists the names of the available subscription plans
+var PlanNames = DefaultCatalog.PlanNames()
 
-	"worldwide": `worldwide plan:
+// PlanDetails maps plan names to their detailed descriptions
+var PlanDetails = planDetails()
 
-Description: Full access to all available regions across the globe.
-Ideal For: Comprehensive monitoring for services with a worldwide user base.
+// LookupPlan returns the plan with the given name
+func LookupPlan(name string) (Plan, bool) {
+	return DefaultCatalog.LookupPlan(name)
+}
 
-Number of Targets: Select the number of targets you wish to monitor across all regions.`,
+func planDetails() map[string]string {
+	details := make(map[string]string, len(Plans))
+	for _, plan := range Plans {
+		details[plan.Name] = DefaultCatalog.PlanDetails(plan)
+	}
+	return details
 }
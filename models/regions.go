@@ -0,0 +1,97 @@
+package models
+
+// Continents in the order regions are listed
+const (
+	ContinentAmericas   = "Americas"
+	ContinentAfrica     = "Africa"
+	ContinentAsia       = "Asia"
+	ContinentOceania    = "Oceania"
+	ContinentEurope     = "Europe"
+	ContinentMiddleEast = "Middle East"
+)
+
+// Continents lists the continents probes run on
+var Continents = []string{
+	ContinentAmericas,
+	ContinentAfrica,
+	ContinentAsia,
+	ContinentOceania,
+	ContinentEurope,
+	ContinentMiddleEast,
+}
+
+// Region is a location probes run from
+type Region struct {
+	Code      string `json:"code" yaml:"code"`
+	Country   string `json:"country" yaml:"country"`
+	City      string `json:"city" yaml:"city"`
+	Continent string `json:"continent" yaml:"continent"`
+}
+
+// Regions lists the available probe regions, grouped by continent
+var Regions = []Region{
+	{Code: "sao-paulo.americas", Country: "Brazil", City: "São Paulo", Continent: ContinentAmericas},
+	{Code: "canada.americas", Country: "Canada", City: "Montréal", Continent: ContinentAmericas},
+	{Code: "calgary.americas", Country: "Canada", City: "Calgary", Continent: ContinentAmericas},
+	{Code: "northern-virginia.americas", Country: "United States", City: "Northern Virginia", Continent: ContinentAmericas},
+	{Code: "ohio.americas", Country: "United States", City: "Ohio", Continent: ContinentAmericas},
+	{Code: "northern-california.americas", Country: "United States", City: "Northern California", Continent: ContinentAmericas},
+	{Code: "oregon.americas", Country: "United States", City: "Oregon", Continent: ContinentAmericas},
+	{Code: "cape-town.africa", Country: "South Africa", City: "Cape Town", Continent: ContinentAfrica},
+	{Code: "tokyo.asia", Country: "Japan", City: "Tokyo", Continent: ContinentAsia},
+	{Code: "osaka.asia", Country: "Japan", City: "Osaka", Continent: ContinentAsia},
+	{Code: "hong-kong.asia", Country: "Hong Kong", City: "Hong Kong", Continent: ContinentAsia},
+	{Code: "hyderabad.asia", Country: "India", City: "Hyderabad", Continent: ContinentAsia},
+	{Code: "mumbai.asia", Country: "India", City: "Mumbai", Continent: ContinentAsia},
+	{Code: "jakarta.asia", Country: "Indonesia", City: "Jakarta", Continent: ContinentAsia},
+	{Code: "malaysia.asia", Country: "Malaysia", City: "Kuala Lumpur", Continent: ContinentAsia},
+	{Code: "seoul.asia", Country: "South Korea", City: "Seoul", Continent: ContinentAsia},
+	{Code: "singapore.asia", Country: "Singapore", City: "Singapore", Continent: ContinentAsia},
+	{Code: "melbourne.oceania", Country: "Australia", City: "Melbourne", Continent: ContinentOceania},
+	{Code: "sydney.oceania", Country: "Australia", City: "Sydney", Continent: ContinentOceania},
+	{Code: "london.europe", Country: "United Kingdom", City: "London", Continent: ContinentEurope},
+	{Code: "frankfurt.europe", Country: "Germany", City: "Frankfurt", Continent: ContinentEurope},
+	{Code: "ireland.europe", Country: "Ireland", City: "Dublin", Continent: ContinentEurope},
+	{Code: "milan.europe", Country: "Italy", City: "Milan", Continent: ContinentEurope},
+	{Code: "paris.europe", Country: "France", City: "Paris", Continent: ContinentEurope},
+	{Code: "spain.europe", Country: "Spain", City: "Aragón", Continent: ContinentEurope},
+	{Code: "stockholm.europe", Country: "Sweden", City: "Stockholm", Continent: ContinentEurope},
+	{Code: "zurich.europe", Country: "Switzerland", City: "Zurich", Continent: ContinentEurope},
+	{Code: "tel-aviv.middle-east", Country: "Israel", City: "Tel Aviv", Continent: ContinentMiddleEast},
+	{Code: "bahrain.middle-east", Country: "Bahrain", City: "Manama", Continent: ContinentMiddleEast},
+	{Code: "uae.middle-east", Country: "United Arab Emirates", City: "Dubai", Continent: ContinentMiddleEast},
+}
+
+// LookupRegion returns the region with the given code
+func LookupRegion(code string) (Region, bool) {
+	return DefaultCatalog.LookupRegion(code)
+}
+
+// SuggestRegions returns the codes of the regions closest to an unknown
+// region code, see Catalog.SuggestRegions
+func SuggestRegions(input string) []string {
+	return DefaultCatalog.SuggestRegions(input)
+}
+
+// levenshtein returns the edit distance between a and b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}